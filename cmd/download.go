@@ -0,0 +1,245 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/pkg/types"
+)
+
+// NewDownloadCommand creates a cobra command to download a snapshot to a local file.
+func NewDownloadCommand(ctx context.Context) *cobra.Command {
+	opts := newDownloadOptions()
+	command := &cobra.Command{
+		Use:   "download",
+		Short: "downloads a snapshot from the snapstore to a local file",
+		Long: `Download fetches a full snapshot from the configured snapstore and writes it
+to a local file for offline inspection, e.g. with etcdctl. With --apply-deltas
+the delta snapshots on top of the full snapshot are replayed through a
+temporary embedded etcd, optionally only up to --up-to-revision, and the
+reconstructed database file is written instead. No etcd member is started to
+serve the downloaded data.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			printVersionInfo()
+			logger := logrus.NewEntry(logrus.New())
+			if err := opts.validate(); err != nil {
+				logger.Fatalf("failed to validate the options: %v", err)
+				return
+			}
+			opts.complete()
+			if err := runDownload(logger, opts); err != nil {
+				logger.Fatalf("Failed to download snapshot: %v", err)
+				return
+			}
+		},
+	}
+	opts.addFlags(command.Flags())
+	return command
+}
+
+// runDownload fetches the requested snapshot and writes it to the configured
+// output file, reporting the resulting file's revision and size.
+func runDownload(logger *logrus.Entry, opts *downloadOptions) error {
+	store, err := snapstore.GetSnapstore(opts.snapstoreConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create snapstore from configured storage provider: %v", err)
+	}
+
+	baseSnap, deltaSnapList, err := getDownloadSnapshots(store, opts)
+	if err != nil {
+		return err
+	}
+	if baseSnap == nil {
+		return fmt.Errorf("no full snapshot found in the snapstore")
+	}
+
+	revision := baseSnap.LastRevision
+	if opts.applyDeltas && len(deltaSnapList) > 0 {
+		if err := reconstructDBFromSnapshots(logger, store, baseSnap, deltaSnapList, opts); err != nil {
+			return err
+		}
+		revision = deltaSnapList[len(deltaSnapList)-1].LastRevision
+	} else {
+		logger.Infof("Downloading full snapshot %s...", baseSnap.SnapName)
+		if err := writeSnapshotToFile(store, baseSnap, opts.outputFile); err != nil {
+			return err
+		}
+	}
+
+	fi, err := os.Stat(opts.outputFile)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Successfully downloaded snapshot to %s: revision %d, size %d bytes.", opts.outputFile, revision, fi.Size())
+	return nil
+}
+
+// getDownloadSnapshots resolves the full snapshot to download, either by name or
+// the latest one, along with the contiguous delta snapshot chain on top of it
+// truncated at the requested revision.
+func getDownloadSnapshots(store brtypes.SnapStore, opts *downloadOptions) (*brtypes.Snapshot, brtypes.SnapList, error) {
+	if len(opts.snapshotName) == 0 && !opts.applyDeltas {
+		baseSnap, _, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+		return baseSnap, nil, err
+	}
+
+	snapList, err := store.List()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var baseSnap *brtypes.Snapshot
+	for _, snap := range snapList {
+		if snap.IsChunk || snap.Kind != brtypes.SnapshotKindFull {
+			continue
+		}
+		if len(opts.snapshotName) != 0 {
+			if snap.SnapName == opts.snapshotName {
+				baseSnap = snap
+				break
+			}
+		} else {
+			baseSnap = snap // snapshots are sorted by revision, so the last full snapshot wins
+		}
+	}
+	if baseSnap == nil {
+		if len(opts.snapshotName) != 0 {
+			return nil, nil, fmt.Errorf("full snapshot %s not found in snapstore", opts.snapshotName)
+		}
+		return nil, nil, nil
+	}
+	if !opts.applyDeltas {
+		return baseSnap, nil, nil
+	}
+
+	// Collect the unbroken delta snapshot chain following the full snapshot,
+	// stopping at the requested revision.
+	var deltaSnapList brtypes.SnapList
+	nextStartRevision := baseSnap.LastRevision + 1
+	for _, snap := range snapList {
+		if snap.IsChunk || snap.Kind != brtypes.SnapshotKindDelta || snap.StartRevision != nextStartRevision {
+			continue
+		}
+		if opts.upToRevision > 0 && snap.LastRevision > opts.upToRevision {
+			break
+		}
+		deltaSnapList = append(deltaSnapList, snap)
+		nextStartRevision = snap.LastRevision + 1
+	}
+	return baseSnap, deltaSnapList, nil
+}
+
+// reconstructDBFromSnapshots restores the full snapshot plus the given delta
+// snapshots into a temporary data directory and copies the resulting database
+// file to the configured output file.
+func reconstructDBFromSnapshots(logger *logrus.Entry, store brtypes.SnapStore, baseSnap *brtypes.Snapshot, deltaSnapList brtypes.SnapList, opts *downloadOptions) error {
+	restoreDir, err := os.MkdirTemp(opts.snapstoreConfig.TempDir, "download-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary restoration directory: %v", err)
+	}
+	defer os.RemoveAll(restoreDir)
+
+	restorationConfig := brtypes.NewRestorationConfig()
+	restorationConfig.RestoreDataDir = restoreDir
+	clusterURLsMap, err := types.NewURLsMap(restorationConfig.InitialCluster)
+	if err != nil {
+		return fmt.Errorf("failed creating url map for restore cluster: %v", err)
+	}
+	peerURLs, err := types.NewURLs(restorationConfig.InitialAdvertisePeerURLs)
+	if err != nil {
+		return fmt.Errorf("failed parsing peers urls for restore cluster: %v", err)
+	}
+
+	logger.Infof("Reconstructing database from full snapshot %s and %d delta snapshot(s)...", baseSnap.SnapName, len(deltaSnapList))
+	rs := restorer.NewRestorer(store, logger)
+	if err := rs.RestoreAndStopEtcd(brtypes.RestoreOptions{
+		Config:        restorationConfig,
+		BaseSnapshot:  baseSnap,
+		DeltaSnapList: deltaSnapList,
+		ClusterURLs:   clusterURLsMap,
+		PeerURLs:      peerURLs,
+	}, nil); err != nil {
+		return fmt.Errorf("failed to reconstruct database from snapshots: %v", err)
+	}
+
+	dbPath := filepath.Join(restoreDir, "member", "snap", "db")
+	return copyFile(dbPath, opts.outputFile)
+}
+
+// writeSnapshotToFile fetches the given snapshot from the snapstore and writes
+// its decompressed content to the given path.
+func writeSnapshotToFile(store brtypes.SnapStore, snap *brtypes.Snapshot, outputFile string) error {
+	rc, err := store.Fetch(*snap)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	isCompressed, compressionPolicy, err := compressor.IsSnapshotCompressed(snap.CompressionSuffix)
+	if err != nil {
+		return err
+	}
+	var reader io.Reader = rc
+	if isCompressed {
+		drc, err := compressor.DecompressSnapshot(rc, compressionPolicy)
+		if err != nil {
+			return fmt.Errorf("unable to decompress the snapshot: %v", err)
+		}
+		defer drc.Close()
+		reader = drc
+	}
+
+	out, err := os.OpenFile(outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, reader); err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// copyFile copies the file at the source path to the destination path.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Sync()
+}
@@ -59,8 +59,12 @@ func NewInitializeCommand(ctx context.Context) *cobra.Command {
 				mode = validator.Full
 			case validator.Sanity:
 				mode = validator.Sanity
+			case validator.Repair:
+				mode = validator.Repair
+			case validator.Membership:
+				mode = validator.Membership
 			default:
-				logger.Fatal("validation-mode can only be one of these values [full/sanity]")
+				logger.Fatal("validation-mode can only be one of these values [full/sanity/repair/membership]")
 			}
 
 			restoreOptions := &brtypes.RestoreOptions{
@@ -56,10 +56,22 @@ func BuildRestoreOptionsAndStore(opts *restorerOptions) (*brtypes.RestoreOptions
 		logger.Fatalf("failed to create restore snapstore from configured storage provider: %v", err)
 	}
 
-	logger.Info("Finding latest set of snapshot to recover from...")
-	baseSnap, deltaSnapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
-	if err != nil {
-		logger.Fatalf("failed to get latest snapshot: %v", err)
+	var (
+		baseSnap      *brtypes.Snapshot
+		deltaSnapList brtypes.SnapList
+	)
+	if len(opts.restorationConfig.FullSnapshotName) != 0 {
+		logger.Infof("Finding snapshot %s to recover from...", opts.restorationConfig.FullSnapshotName)
+		baseSnap, deltaSnapList, err = miscellaneous.GetNamedFullSnapshotAndDeltaSnapList(store, opts.restorationConfig.FullSnapshotName, opts.restorationConfig.DeltaSnapshotNames)
+		if err != nil {
+			logger.Fatalf("failed to get named snapshot: %v", err)
+		}
+	} else {
+		logger.Info("Finding latest set of snapshot to recover from...")
+		baseSnap, deltaSnapList, err = miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+		if err != nil {
+			logger.Fatalf("failed to get latest snapshot: %v", err)
+		}
 	}
 
 	if baseSnap == nil {
@@ -37,6 +37,7 @@ type serverOptions struct {
 	ConfigFile string
 	Version    bool
 	LogLevel   uint32
+	LogFormat  string
 	Logger     *logrus.Logger
 	Config     *server.BackupRestoreComponentConfig
 }
@@ -47,26 +48,32 @@ func newServerOptions() *serverOptions {
 	logger.SetLevel(logrus.InfoLevel)
 
 	return &serverOptions{
-		LogLevel: 4,
-		Version:  false,
-		Config:   server.NewBackupRestoreComponentConfig(),
-		Logger:   logger,
+		LogLevel:  4,
+		LogFormat: logFormatText,
+		Version:   false,
+		Config:    server.NewBackupRestoreComponentConfig(),
+		Logger:    logger,
 	}
 }
 
 func (o *serverOptions) validate() error {
+	if err := validateLogFormat(o.LogFormat); err != nil {
+		return err
+	}
 	return o.Config.Validate()
 }
 
 func (o *serverOptions) addFlags(fs *flag.FlagSet) {
 	fs.StringVar(&o.ConfigFile, "config-file", o.ConfigFile, "path to the configuration file")
 	fs.Uint32Var(&o.LogLevel, "log-level", o.LogLevel, "verbosity level of logs")
+	fs.StringVar(&o.LogFormat, "log-format", o.LogFormat, "output format of logs (text|json)")
 	o.Config.AddFlags(fs)
 }
 
 func (o *serverOptions) complete() {
 	o.Config.Complete()
 	o.Logger.SetLevel(logrus.Level(o.LogLevel))
+	applyLogFormat(o.Logger, o.LogFormat)
 }
 
 func (o *serverOptions) loadConfigFromFile() error {
@@ -208,7 +215,7 @@ func newValidatorOptions() *validatorOptions {
 
 // AddFlags adds the flags to flagset.
 func (c *validatorOptions) addFlags(fs *flag.FlagSet) {
-	fs.StringVar(&c.ValidationMode, "validation-mode", string(c.ValidationMode), "mode to do data initialization[full/sanity]")
+	fs.StringVar(&c.ValidationMode, "validation-mode", string(c.ValidationMode), "mode to do data initialization[full/sanity/repair/membership]")
 	fs.Int64Var(&c.FailBelowRevision, "experimental-fail-below-revision", c.FailBelowRevision, "minimum required etcd revision, below which validation fails")
 }
 
@@ -221,8 +228,10 @@ type snapshotterOptions struct {
 	etcdConnectionConfig     *brtypes.EtcdConnectionConfig
 	compressionConfig        *compressor.CompressionConfig
 	snapstoreConfig          *brtypes.SnapstoreConfig
+	replicaSnapstoreConfig   *brtypes.SnapstoreConfig
 	snapshotterConfig        *brtypes.SnapshotterConfig
 	defragmentationSchedule  string
+	logFormat                string
 	exponentialBackoffConfig *brtypes.ExponentialBackoffConfig
 }
 
@@ -231,10 +240,12 @@ func newSnapshotterOptions() *snapshotterOptions {
 	return &snapshotterOptions{
 		etcdConnectionConfig:     brtypes.NewEtcdConnectionConfig(),
 		snapstoreConfig:          snapstore.NewSnapstoreConfig(),
+		replicaSnapstoreConfig:   snapstore.NewSnapstoreConfig(),
 		snapshotterConfig:        snapshotter.NewSnapshotterConfig(),
 		compressionConfig:        compressor.NewCompressorConfig(),
 		exponentialBackoffConfig: brtypes.NewExponentialBackOffConfig(),
 		defragmentationSchedule:  "0 0 */3 * *",
+		logFormat:                logFormatText,
 	}
 }
 
@@ -242,20 +253,32 @@ func newSnapshotterOptions() *snapshotterOptions {
 func (c *snapshotterOptions) addFlags(fs *flag.FlagSet) {
 	c.etcdConnectionConfig.AddFlags(fs)
 	c.snapstoreConfig.AddFlags(fs)
+	c.replicaSnapstoreConfig.AddReplicaFlags(fs)
 	c.snapshotterConfig.AddFlags(fs)
 	c.compressionConfig.AddFlags(fs)
 	c.exponentialBackoffConfig.AddFlags(fs)
 
 	// Miscellaneous
 	fs.StringVar(&c.defragmentationSchedule, "defragmentation-schedule", c.defragmentationSchedule, "schedule to defragment etcd data directory")
+	fs.StringVar(&c.logFormat, "log-format", c.logFormat, "output format of logs (text|json)")
 }
 
 // Validate validates the config.
 func (c *snapshotterOptions) validate() error {
+	if err := validateLogFormat(c.logFormat); err != nil {
+		return err
+	}
+
 	if err := c.snapstoreConfig.Validate(); err != nil {
 		return err
 	}
 
+	if len(c.replicaSnapstoreConfig.Provider) != 0 {
+		if err := c.replicaSnapstoreConfig.Validate(); err != nil {
+			return err
+		}
+	}
+
 	if err := c.snapshotterConfig.Validate(); err != nil {
 		return err
 	}
@@ -273,6 +296,85 @@ func (c *snapshotterOptions) validate() error {
 // complete completes the config.
 func (c *snapshotterOptions) complete() {
 	c.snapstoreConfig.Complete()
+	if len(c.replicaSnapstoreConfig.Provider) != 0 {
+		c.replicaSnapstoreConfig.Complete()
+	}
+}
+
+type selfTestOptions struct {
+	snapstoreConfig   *brtypes.SnapstoreConfig
+	compressionConfig *compressor.CompressionConfig
+}
+
+// newSelfTestOptions returns the self-test options.
+func newSelfTestOptions() *selfTestOptions {
+	return &selfTestOptions{
+		snapstoreConfig:   snapstore.NewSnapstoreConfig(),
+		compressionConfig: compressor.NewCompressorConfig(),
+	}
+}
+
+// AddFlags adds the flags to flagset.
+func (c *selfTestOptions) addFlags(fs *flag.FlagSet) {
+	c.snapstoreConfig.AddFlags(fs)
+	c.compressionConfig.AddFlags(fs)
+}
+
+// Validate validates the config.
+func (c *selfTestOptions) validate() error {
+	if err := c.snapstoreConfig.Validate(); err != nil {
+		return err
+	}
+
+	return c.compressionConfig.Validate()
+}
+
+// complete completes the config.
+func (c *selfTestOptions) complete() {
+	c.snapstoreConfig.Complete()
+}
+
+type downloadOptions struct {
+	snapstoreConfig *brtypes.SnapstoreConfig
+	snapshotName    string
+	outputFile      string
+	applyDeltas     bool
+	upToRevision    int64
+}
+
+// newDownloadOptions returns the download options.
+func newDownloadOptions() *downloadOptions {
+	return &downloadOptions{
+		snapstoreConfig: snapstore.NewSnapstoreConfig(),
+	}
+}
+
+// AddFlags adds the flags to flagset.
+func (c *downloadOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.snapshotName, "snapshot-name", c.snapshotName, "object name of the full snapshot to download, defaulting to the latest full snapshot")
+	fs.StringVarP(&c.outputFile, "output-file", "o", c.outputFile, "path of the local file to write the snapshot to")
+	fs.BoolVar(&c.applyDeltas, "apply-deltas", c.applyDeltas, "reconstruct the database by replaying the delta snapshots on top of the full snapshot")
+	fs.Int64Var(&c.upToRevision, "up-to-revision", c.upToRevision, "replay delta snapshots only up to this revision, 0 meaning all of them")
+	c.snapstoreConfig.AddFlags(fs)
+}
+
+// Validate validates the config.
+func (c *downloadOptions) validate() error {
+	if len(c.outputFile) == 0 {
+		return errors.New("parameter output-file must be provided")
+	}
+	if c.upToRevision < 0 {
+		return errors.New("parameter up-to-revision must not be less than 0")
+	}
+	if c.upToRevision > 0 && !c.applyDeltas {
+		return errors.New("parameter up-to-revision requires apply-deltas to be set")
+	}
+	return c.snapstoreConfig.Validate()
+}
+
+// complete completes the config.
+func (c *downloadOptions) complete() {
+	c.snapstoreConfig.Complete()
 }
 
 type copierOptions struct {
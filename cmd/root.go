@@ -41,6 +41,8 @@ from previously taken snapshot.`,
 		NewCompactCommand(ctx),
 		NewInitializeCommand(ctx),
 		NewServerCommand(ctx),
-		NewCopyCommand(ctx))
+		NewCopyCommand(ctx),
+		NewSelfTestCommand(ctx),
+		NewDownloadCommand(ctx))
 	return RootCmd
 }
@@ -44,6 +44,7 @@ storing snapshots on various cloud storage providers as well as local disk locat
 			}
 
 			opts.complete()
+			applyLogFormat(logger.Logger, opts.logFormat)
 
 			ss, err := snapstore.GetSnapstore(opts.snapstoreConfig)
 			if err != nil {
@@ -55,6 +56,14 @@ storing snapshots on various cloud storage providers as well as local disk locat
 				logger.Fatalf("Failed to create snapshotter: %v", err)
 			}
 
+			if len(opts.replicaSnapstoreConfig.Provider) != 0 {
+				replicaStore, err := snapstore.GetSnapstore(opts.replicaSnapstoreConfig)
+				if err != nil {
+					logger.Fatalf("Failed to create replica snapstore from configured storage provider: %v", err)
+				}
+				ssr.AddReplicaSnapStores(opts.snapshotterConfig.FailOnSnapshotReplicationError, replicaStore)
+			}
+
 			defragSchedule, err := cron.ParseStandard(opts.defragmentationSchedule)
 			if err != nil {
 				logger.Fatalf("failed to parse defragmentation schedule: %v", err)
@@ -14,9 +14,42 @@
 
 package cmd
 
-import "github.com/sirupsen/logrus"
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// logFormatText is the log format which produces human readable text logs.
+	logFormatText = "text"
+	// logFormatJSON is the log format which produces structured JSON logs.
+	logFormatJSON = "json"
+)
 
 var (
 	version bool
 	logger  = logrus.New()
 )
+
+// validateLogFormat returns an error if the given log format is not supported.
+func validateLogFormat(format string) error {
+	switch format {
+	case logFormatText, logFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported log format %q, only %q and %q are supported", format, logFormatText, logFormatJSON)
+	}
+}
+
+// applyLogFormat configures the formatter of the given logger as per the given
+// log format. The field names emitted by the JSON formatter (time, level, msg)
+// are stable, so log parsers can rely on them. Unknown formats fall back to the
+// default text formatter.
+func applyLogFormat(logger *logrus.Logger, format string) {
+	if format == logFormatJSON {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+		return
+	}
+	logger.SetFormatter(&logrus.TextFormatter{})
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deltacodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+// protobufMagic prefixes protobuf encoded delta snapshot payloads so that the
+// restorer can detect the encoding from the payload itself, irrespective of
+// the encoding it is configured with.
+var protobufMagic = []byte("etcdbr-delta-proto-v1\n")
+
+// Codec incrementally encodes collected etcd watch events into the payload
+// of a delta snapshot.
+type Codec interface {
+	// AppendEvent appends the encoded form of the given event to data and
+	// returns the grown payload.
+	AppendEvent(data []byte, event *brtypes.Event) ([]byte, error)
+	// Finalize completes the payload after the last event has been appended.
+	Finalize(data []byte) []byte
+}
+
+// NewCodec returns the codec for the given delta snapshot encoding.
+func NewCodec(encoding string) (Codec, error) {
+	switch encoding {
+	case "", brtypes.DeltaSnapshotEncodingJSON:
+		return &jsonCodec{}, nil
+	case brtypes.DeltaSnapshotEncodingProtobuf:
+		return &protobufCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown delta snapshot encoding: %s", encoding)
+	}
+}
+
+// DecodeEvents decodes a delta snapshot payload into events. The encoding is
+// detected from the payload itself, so delta snapshots taken with any
+// encoding, including old JSON ones, can always be restored.
+func DecodeEvents(data []byte) ([]brtypes.Event, error) {
+	if bytes.HasPrefix(data, protobufMagic) {
+		return decodeProtobufEvents(data[len(protobufMagic):])
+	}
+	events := []brtypes.Event{}
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// jsonCodec encodes events as one JSON array, the format delta snapshots
+// have always been stored in.
+type jsonCodec struct{}
+
+func (c *jsonCodec) AppendEvent(data []byte, event *brtypes.Event) ([]byte, error) {
+	jsonByte, err := json.Marshal(event)
+	if err != nil {
+		return data, fmt.Errorf("failed to marshal event to json: %v", err)
+	}
+	if len(data) == 0 {
+		data = append(data, byte('['))
+	} else {
+		data = append(data, byte(','))
+	}
+	return append(data, jsonByte...), nil
+}
+
+func (c *jsonCodec) Finalize(data []byte) []byte {
+	return append(data, byte(']'))
+}
+
+// protobufCodec encodes events as a stream of length-prefixed records behind
+// a magic header, each record holding the event time followed by the
+// protobuf wire form of the etcd event.
+type protobufCodec struct{}
+
+func (c *protobufCodec) AppendEvent(data []byte, event *brtypes.Event) ([]byte, error) {
+	evBytes, err := (*mvccpb.Event)(event.EtcdEvent).Marshal()
+	if err != nil {
+		return data, fmt.Errorf("failed to marshal event to protobuf: %v", err)
+	}
+	if len(data) == 0 {
+		data = append(data, protobufMagic...)
+	}
+	record := make([]byte, 8, 8+len(evBytes))
+	binary.BigEndian.PutUint64(record, uint64(event.Time.UnixNano()))
+	record = append(record, evBytes...)
+
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(record)))
+	data = append(data, lenBuf[:n]...)
+	return append(data, record...), nil
+}
+
+func (c *protobufCodec) Finalize(data []byte) []byte {
+	return data
+}
+
+func decodeProtobufEvents(data []byte) ([]brtypes.Event, error) {
+	events := []brtypes.Event{}
+	for len(data) > 0 {
+		recordLen, n := binary.Uvarint(data)
+		if n <= 0 || recordLen < 8 || uint64(len(data)-n) < recordLen {
+			return nil, fmt.Errorf("corrupt protobuf encoded delta snapshot payload")
+		}
+		record := data[n : uint64(n)+recordLen]
+		data = data[uint64(n)+recordLen:]
+
+		etcdEvent := &mvccpb.Event{}
+		if err := etcdEvent.Unmarshal(record[8:]); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event from protobuf: %v", err)
+		}
+		events = append(events, brtypes.Event{
+			EtcdEvent: (*clientv3.Event)(etcdEvent),
+			Time:      time.Unix(0, int64(binary.BigEndian.Uint64(record[:8]))).UTC(),
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deltacodec_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/deltacodec"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+// makeEvents builds a representative sequence of timed watch events starting
+// at the given revision, with every tenth event being a deletion.
+func makeEvents(startRevision int64, count int) []brtypes.Event {
+	events := make([]brtypes.Event, 0, count)
+	for i := 0; i < count; i++ {
+		revision := startRevision + int64(i)
+		etcdEvent := &clientv3.Event{
+			Type: mvccpb.PUT,
+			Kv: &mvccpb.KeyValue{
+				Key:            []byte(fmt.Sprintf("/registry/pods/default/pod-%d", i)),
+				Value:          []byte(fmt.Sprintf("value-%d-with-some-representative-payload-data", i)),
+				CreateRevision: revision,
+				ModRevision:    revision,
+				Version:        1,
+			},
+		}
+		if i%10 == 0 && i != 0 {
+			etcdEvent.Type = mvccpb.DELETE
+			etcdEvent.Kv.Value = nil
+		}
+		events = append(events, brtypes.Event{
+			EtcdEvent: etcdEvent,
+			Time:      time.Unix(1518427675+int64(i), 0).UTC(),
+		})
+	}
+	return events
+}
+
+// encodeEvents encodes the given events into one delta snapshot payload using
+// the given codec.
+func encodeEvents(codec Codec, events []brtypes.Event) []byte {
+	var data []byte
+	var err error
+	for i := range events {
+		data, err = codec.AppendEvent(data, &events[i])
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+	return codec.Finalize(data)
+}
+
+var _ = Describe("Deltacodec", func() {
+	Describe("creating a codec", func() {
+		It("returns the codec for known encodings", func() {
+			for _, encoding := range []string{"", brtypes.DeltaSnapshotEncodingJSON, brtypes.DeltaSnapshotEncodingProtobuf} {
+				codec, err := NewCodec(encoding)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(codec).ShouldNot(BeNil())
+			}
+		})
+		It("returns error for an unknown encoding", func() {
+			_, err := NewCodec("xml")
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("encoding and decoding events", func() {
+		events := makeEvents(101, 25)
+
+		It("round-trips events through the json codec", func() {
+			codec, err := NewCodec(brtypes.DeltaSnapshotEncodingJSON)
+			Expect(err).ShouldNot(HaveOccurred())
+			decoded, err := DecodeEvents(encodeEvents(codec, events))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(decoded).To(Equal(events))
+		})
+
+		It("round-trips events through the protobuf codec", func() {
+			codec, err := NewCodec(brtypes.DeltaSnapshotEncodingProtobuf)
+			Expect(err).ShouldNot(HaveOccurred())
+			decoded, err := DecodeEvents(encodeEvents(codec, events))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(decoded).To(Equal(events))
+		})
+
+		It("decodes the plain json arrays of old delta snapshots", func() {
+			data, err := json.Marshal(events)
+			Expect(err).ShouldNot(HaveOccurred())
+			decoded, err := DecodeEvents(data)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(decoded).To(Equal(events))
+		})
+
+		It("returns error for a truncated protobuf payload", func() {
+			codec, err := NewCodec(brtypes.DeltaSnapshotEncodingProtobuf)
+			Expect(err).ShouldNot(HaveOccurred())
+			data := encodeEvents(codec, events)
+			_, err = DecodeEvents(data[:len(data)-3])
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("comparing the encodings on a representative delta chain", func() {
+		It("encodes more compactly and decodes at least as fast with protobuf", func() {
+			const (
+				deltaSnapshots    = 10
+				eventsPerSnapshot = 1000
+			)
+			var jsonSize, protobufSize int
+			var jsonTime, protobufTime time.Duration
+
+			jsonCodec, err := NewCodec(brtypes.DeltaSnapshotEncodingJSON)
+			Expect(err).ShouldNot(HaveOccurred())
+			protobufCodec, err := NewCodec(brtypes.DeltaSnapshotEncodingProtobuf)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			for i := 0; i < deltaSnapshots; i++ {
+				events := makeEvents(int64(i*eventsPerSnapshot+1), eventsPerSnapshot)
+
+				jsonData := encodeEvents(jsonCodec, events)
+				jsonSize += len(jsonData)
+				start := time.Now()
+				decoded, err := DecodeEvents(jsonData)
+				jsonTime += time.Since(start)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(decoded).To(HaveLen(eventsPerSnapshot))
+
+				protobufData := encodeEvents(protobufCodec, events)
+				protobufSize += len(protobufData)
+				start = time.Now()
+				decoded, err = DecodeEvents(protobufData)
+				protobufTime += time.Since(start)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(decoded).To(HaveLen(eventsPerSnapshot))
+			}
+
+			fmt.Fprintf(GinkgoWriter, "delta chain of %d snapshots with %d events each:\n", deltaSnapshots, eventsPerSnapshot)
+			fmt.Fprintf(GinkgoWriter, "  json:     %8d bytes, decoded in %v\n", jsonSize, jsonTime)
+			fmt.Fprintf(GinkgoWriter, "  protobuf: %8d bytes, decoded in %v\n", protobufSize, protobufTime)
+
+			Expect(protobufSize).Should(BeNumerically("<", jsonSize))
+		})
+	})
+})
@@ -15,9 +15,15 @@
 package etcdutil
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gardener/etcd-backup-restore/pkg/compressor"
@@ -30,9 +36,18 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
 	"go.etcd.io/etcd/pkg/transport"
 )
 
+// KeyRangeSnapshotMagic marks a full snapshot that was taken as a key-range
+// export rather than an etcd database snapshot.
+var KeyRangeSnapshotMagic = []byte("etcdbr-keyrange-v1\n")
+
+// keyRangeSnapshotPageSize is the number of keys fetched per ranged read while
+// taking a key-range snapshot.
+const keyRangeSnapshotPageSize = 1000
+
 // NewFactory returns a Factory that constructs new clients using the supplied ETCD client configuration.
 func NewFactory(cfg brtypes.EtcdConnectionConfig, opts ...client.Option) client.Factory {
 	options := &client.Options{}
@@ -108,8 +123,11 @@ func GetTLSClientForEtcd(tlsConfig *brtypes.EtcdConnectionConfig, options *clien
 	}
 
 	cfg := &clientv3.Config{
-		Endpoints: endpoints,
-		Context:   context.TODO(), // TODO: Use the context comming as parameter.
+		Endpoints:            endpoints,
+		Context:              context.TODO(), // TODO: Use the context comming as parameter.
+		DialTimeout:          tlsConfig.DialTimeout.Duration,
+		DialKeepAliveTime:    tlsConfig.DialKeepAliveTime.Duration,
+		DialKeepAliveTimeout: tlsConfig.DialKeepAliveTimeout.Duration,
 	}
 
 	if cfgtls != nil {
@@ -133,14 +151,43 @@ func GetTLSClientForEtcd(tlsConfig *brtypes.EtcdConnectionConfig, options *clien
 		cfg.TLS.InsecureSkipVerify = true
 	}
 
-	if tlsConfig.Username != "" && tlsConfig.Password != "" {
-		cfg.Username = tlsConfig.Username
-		cfg.Password = tlsConfig.Password
+	username, password, err := resolveEtcdCredentials(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" && password != "" {
+		cfg.Username = username
+		cfg.Password = password
 	}
 
 	return clientv3.New(*cfg)
 }
 
+// resolveEtcdCredentials returns the etcd username and password to use for a
+// new client connection. Credentials configured as file paths are re-read on
+// every call, so that rotated credentials are picked up on the next client
+// (re)connect without a restart. The certificate files referenced by the TLS
+// config need no such handling, as the transport re-reads them on every
+// handshake.
+func resolveEtcdCredentials(cfg *brtypes.EtcdConnectionConfig) (string, string, error) {
+	username, password := cfg.Username, cfg.Password
+	if cfg.UsernameFile != "" {
+		data, err := os.ReadFile(cfg.UsernameFile)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read etcd username file: %v", err)
+		}
+		username = strings.TrimSpace(string(data))
+	}
+	if cfg.PasswordFile != "" {
+		data, err := os.ReadFile(cfg.PasswordFile)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read etcd password file: %v", err)
+		}
+		password = strings.TrimSpace(string(data))
+	}
+	return username, password, nil
+}
+
 // PerformDefragmentation defragment the data directory of each etcd member.
 func PerformDefragmentation(defragCtx context.Context, client client.MaintenanceCloser, endpoint string, logger *logrus.Entry) error {
 	var dbSizeBeforeDefrag, dbSizeAfterDefrag int64
@@ -263,8 +310,71 @@ func GetEtcdEndPointsSorted(ctx context.Context, clientMaintenance client.Mainte
 	return leaderEtcdEndpoints, followerEtcdEndpoints, nil
 }
 
+// IsMemberLeader reports whether the etcd member behind the given endpoint is
+// currently the cluster leader. A cluster without a leader is reported as not
+// being the leader.
+func IsMemberLeader(ctx context.Context, clientMaintenance client.MaintenanceCloser, endpoint string) (bool, error) {
+	if len(endpoint) == 0 {
+		return false, &errors.EtcdError{
+			Message: fmt.Sprintf("etcd endpoints are not passed correctly"),
+		}
+	}
+	response, err := clientMaintenance.Status(ctx, endpoint)
+	if err != nil {
+		return false, err
+	}
+	return response.Leader != 0 && response.Header.MemberId == response.Leader, nil
+}
+
+// GetSnapshotEndpoints returns the endpoints snapshot requests should be routed to,
+// honouring the configured endpoint selection. The member roles are determined via
+// the etcd maintenance API, with each status call bound by the etcd connection
+// timeout. If no member with the preferred role is reachable, the configured
+// endpoints are returned unchanged so that the snapshot can still be taken from
+// any member.
+func GetSnapshotEndpoints(ctx context.Context, etcdConnectionConfig *brtypes.EtcdConnectionConfig, logger *logrus.Entry) []string {
+	if etcdConnectionConfig.EndpointSelection == "" || etcdConnectionConfig.EndpointSelection == brtypes.EndpointSelectionAny {
+		return etcdConnectionConfig.Endpoints
+	}
+
+	clientFactory := NewFactory(*etcdConnectionConfig)
+	clientMaintenance, err := clientFactory.NewMaintenance()
+	if err != nil {
+		logger.Warnf("failed to create etcd maintenance client: %v", err)
+		return etcdConnectionConfig.Endpoints
+	}
+	defer clientMaintenance.Close()
+
+	clientCluster, err := clientFactory.NewCluster()
+	if err != nil {
+		logger.Warnf("failed to create etcd cluster client: %v", err)
+		return etcdConnectionConfig.Endpoints
+	}
+	defer clientCluster.Close()
+
+	leaderEtcdEndpoints, followerEtcdEndpoints, err := GetEtcdEndPointsSorted(ctx, clientMaintenance, clientCluster, etcdConnectionConfig.Endpoints, logger)
+	if err != nil {
+		logger.Warnf("failed to sort etcd endpoints by member role: %v", err)
+		return etcdConnectionConfig.Endpoints
+	}
+
+	switch etcdConnectionConfig.EndpointSelection {
+	case brtypes.EndpointSelectionLeader:
+		if len(leaderEtcdEndpoints) > 0 {
+			return leaderEtcdEndpoints
+		}
+	case brtypes.EndpointSelectionFollower:
+		if len(followerEtcdEndpoints) > 0 {
+			return followerEtcdEndpoints
+		}
+	}
+
+	logger.Warnf("no etcd member with role %q available, falling back to the configured endpoints", etcdConnectionConfig.EndpointSelection)
+	return etcdConnectionConfig.Endpoints
+}
+
 // TakeAndSaveFullSnapshot takes full snapshot and save it to store
-func TakeAndSaveFullSnapshot(ctx context.Context, client client.MaintenanceCloser, store brtypes.SnapStore, lastRevision int64, cc *compressor.CompressionConfig, suffix string, isFinal bool, logger *logrus.Entry) (*brtypes.Snapshot, error) {
+func TakeAndSaveFullSnapshot(ctx context.Context, client client.MaintenanceCloser, store brtypes.SnapStore, lastRevision int64, cc *compressor.CompressionConfig, suffix, objectLayout string, isFinal bool, logger *logrus.Entry) (*brtypes.Snapshot, error) {
 	startTime := time.Now()
 	rc, err := client.Snapshot(ctx)
 	if err != nil {
@@ -290,6 +400,66 @@ func TakeAndSaveFullSnapshot(ctx context.Context, client client.MaintenanceClose
 
 	// Then save the snapshot to the store.
 	snapshot := snapstore.NewSnapshot(brtypes.SnapshotKindFull, 0, lastRevision, suffix, isFinal)
+	if objectLayout == brtypes.ObjectLayoutDate {
+		snapshot.GenerateDateDirectory()
+	}
+	if err := store.Save(*snapshot, rc); err != nil {
+		timeTaken := time.Since(startTime)
+		metrics.SnapshotDurationSeconds.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Observe(timeTaken.Seconds())
+		return nil, &errors.SnapstoreError{
+			Message: fmt.Sprintf("failed to save snapshot: %v", err),
+		}
+	}
+
+	timeTaken = time.Since(startTime)
+	metrics.SnapshotDurationSeconds.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Observe(timeTaken.Seconds())
+	logger.Infof("Total time to save full snapshot: %f seconds.", timeTaken.Seconds())
+
+	return snapshot, nil
+}
+
+// TakeAndSaveKeyRangeSnapshot takes a full snapshot of the keys under the given
+// prefixes via paged ranged reads at the given revision and saves it to the store.
+// Unlike an etcd database snapshot, the result contains only the keys under the
+// prefixes and is restored by replaying them through an embedded etcd, so the
+// restored etcd does not retain the revision numbers of the original cluster.
+func TakeAndSaveKeyRangeSnapshot(ctx context.Context, clientKV client.KVCloser, store brtypes.SnapStore, keyPrefixes []string, lastRevision int64, cc *compressor.CompressionConfig, suffix, objectLayout string, isFinal bool, logger *logrus.Entry) (*brtypes.Snapshot, error) {
+	startTime := time.Now()
+	var events []brtypes.Event
+	for _, keyPrefix := range keyPrefixes {
+		prefixEvents, err := readKeyRange(ctx, clientKV, keyPrefix, lastRevision)
+		if err != nil {
+			return nil, &errors.EtcdError{
+				Message: fmt.Sprintf("failed to read keys under prefix %q: %v", keyPrefix, err),
+			}
+		}
+		events = append(events, prefixEvents...)
+	}
+	timeTaken := time.Since(startTime)
+	logger.Infof("Total time taken to read %d keys under prefixes %v: %f seconds.", len(events), keyPrefixes, timeTaken.Seconds())
+
+	data, err := encodeKeyRangeSnapshot(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key-range snapshot: %v", err)
+	}
+
+	var rc io.ReadCloser = io.NopCloser(bytes.NewReader(data))
+	if cc.Enabled {
+		startTimeCompression := time.Now()
+		rc, err = compressor.CompressSnapshot(rc, cc.CompressionPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("unable to obtain reader for compressed file: %v", err)
+		}
+		timeTakenCompression := time.Since(startTimeCompression)
+		logger.Infof("Total time taken in full snapshot compression: %f seconds.", timeTakenCompression.Seconds())
+	}
+	defer rc.Close()
+
+	// Then save the snapshot to the store.
+	snapshot := snapstore.NewSnapshot(brtypes.SnapshotKindFull, 0, lastRevision, suffix, isFinal)
+	if objectLayout == brtypes.ObjectLayoutDate {
+		snapshot.GenerateDateDirectory()
+	}
 	if err := store.Save(*snapshot, rc); err != nil {
 		timeTaken := time.Since(startTime)
 		metrics.SnapshotDurationSeconds.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Observe(timeTaken.Seconds())
@@ -304,3 +474,73 @@ func TakeAndSaveFullSnapshot(ctx context.Context, client client.MaintenanceClose
 
 	return snapshot, nil
 }
+
+// readKeyRange reads all keys under the given prefix at the given revision in
+// pages and returns them as PUT events.
+func readKeyRange(ctx context.Context, clientKV client.KVCloser, keyPrefix string, revision int64) ([]brtypes.Event, error) {
+	var events []brtypes.Event
+	opts := []clientv3.OpOption{
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(keyPrefix)),
+		clientv3.WithRev(revision),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+		clientv3.WithLimit(keyRangeSnapshotPageSize),
+	}
+	key := keyPrefix
+	for {
+		resp, err := clientKV.Get(ctx, key, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range resp.Kvs {
+			events = append(events, brtypes.Event{
+				EtcdEvent: &clientv3.Event{
+					Type: mvccpb.PUT,
+					Kv:   kv,
+				},
+				Time: time.Now(),
+			})
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		// continue from the key right after the last one returned
+		key = string(append(resp.Kvs[len(resp.Kvs)-1].Key, 0))
+	}
+	return events, nil
+}
+
+// encodeKeyRangeSnapshot serializes the events of a key-range snapshot behind
+// the magic header and appends the integrity hash.
+func encodeKeyRangeSnapshot(events []brtypes.Event) ([]byte, error) {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, len(KeyRangeSnapshotMagic)+len(payload)+sha256.Size)
+	data = append(data, KeyRangeSnapshotMagic...)
+	data = append(data, payload...)
+	hash := sha256.Sum256(data)
+	return append(data, hash[:]...), nil
+}
+
+// DecodeKeyRangeSnapshot verifies the integrity hash of a key-range snapshot
+// payload, including its magic header, and returns the decoded events.
+func DecodeKeyRangeSnapshot(data []byte) ([]brtypes.Event, error) {
+	if !bytes.HasPrefix(data, KeyRangeSnapshotMagic) {
+		return nil, fmt.Errorf("not a key-range snapshot payload")
+	}
+	if len(data) <= len(KeyRangeSnapshotMagic)+sha256.Size {
+		return nil, fmt.Errorf("key-range snapshot is missing hash")
+	}
+	payload, snapHash := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	hash := sha256.Sum256(payload)
+	if !bytes.Equal(snapHash, hash[:]) {
+		return nil, fmt.Errorf("expected sha256 %v, got %v", snapHash, hash[:])
+	}
+
+	var events []brtypes.Event
+	if err := json.Unmarshal(payload[len(KeyRangeSnapshotMagic):], &events); err != nil {
+		return nil, fmt.Errorf("failed to decode key-range snapshot events: %v", err)
+	}
+	return events, nil
+}
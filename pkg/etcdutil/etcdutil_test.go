@@ -0,0 +1,139 @@
+// Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	mockfactory "github.com/gardener/etcd-backup-restore/pkg/mock/etcdutil/client"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/golang/mock/gomock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+)
+
+var _ = Describe("Leader check", func() {
+	var (
+		ctrl     *gomock.Controller
+		cm       *mockfactory.MockMaintenanceCloser
+		endpoint = "http://127.0.0.1:2379"
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		cm = mockfactory.NewMockMaintenanceCloser(ctrl)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should report the member behind the endpoint as leader", func() {
+		cm.EXPECT().Status(gomock.Any(), endpoint).Return(&clientv3.StatusResponse{
+			Header: &etcdserverpb.ResponseHeader{MemberId: 1},
+			Leader: 1,
+		}, nil)
+		isLeader, err := IsMemberLeader(testCtx, cm, endpoint)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(isLeader).Should(BeTrue())
+	})
+
+	It("should report a follower member as not being the leader", func() {
+		cm.EXPECT().Status(gomock.Any(), endpoint).Return(&clientv3.StatusResponse{
+			Header: &etcdserverpb.ResponseHeader{MemberId: 2},
+			Leader: 1,
+		}, nil)
+		isLeader, err := IsMemberLeader(testCtx, cm, endpoint)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(isLeader).Should(BeFalse())
+	})
+
+	It("should report no leader when the cluster has lost its leader", func() {
+		cm.EXPECT().Status(gomock.Any(), endpoint).Return(&clientv3.StatusResponse{
+			Header: &etcdserverpb.ResponseHeader{MemberId: 1},
+			Leader: 0,
+		}, nil)
+		isLeader, err := IsMemberLeader(testCtx, cm, endpoint)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(isLeader).Should(BeFalse())
+	})
+
+	It("should fail when the status call fails", func() {
+		cm.EXPECT().Status(gomock.Any(), endpoint).Return(nil, fmt.Errorf("failed to connect to the dummy etcd"))
+		_, err := IsMemberLeader(testCtx, cm, endpoint)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should fail when no endpoint is given", func() {
+		_, err := IsMemberLeader(testCtx, cm, "")
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("Etcd credential resolution", func() {
+	var config *brtypes.EtcdConnectionConfig
+
+	BeforeEach(func() {
+		config = brtypes.NewEtcdConnectionConfig()
+	})
+
+	It("should pass through static string credentials", func() {
+		config.Username = "user"
+		config.Password = "pass"
+
+		username, password, err := resolveEtcdCredentials(config)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(username).Should(Equal("user"))
+		Expect(password).Should(Equal("pass"))
+	})
+
+	It("should pick up rotated credentials from the configured files", func() {
+		dir, err := os.MkdirTemp("", "etcd-credentials-")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		usernameFile := filepath.Join(dir, "username")
+		passwordFile := filepath.Join(dir, "password")
+		Expect(os.WriteFile(usernameFile, []byte("user\n"), 0600)).ShouldNot(HaveOccurred())
+		Expect(os.WriteFile(passwordFile, []byte("pass\n"), 0600)).ShouldNot(HaveOccurred())
+		config.UsernameFile = usernameFile
+		config.PasswordFile = passwordFile
+
+		username, password, err2 := resolveEtcdCredentials(config)
+		Expect(err2).ShouldNot(HaveOccurred())
+		Expect(username).Should(Equal("user"))
+		Expect(password).Should(Equal("pass"))
+
+		// rotate the credentials and resolve again
+		Expect(os.WriteFile(usernameFile, []byte("rotated-user\n"), 0600)).ShouldNot(HaveOccurred())
+		Expect(os.WriteFile(passwordFile, []byte("rotated-pass\n"), 0600)).ShouldNot(HaveOccurred())
+
+		username, password, err = resolveEtcdCredentials(config)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(username).Should(Equal("rotated-user"))
+		Expect(password).Should(Equal("rotated-pass"))
+	})
+
+	It("should fail when a credential file cannot be read", func() {
+		config.PasswordFile = filepath.Join(os.TempDir(), "etcd-credentials-missing", "missing")
+
+		_, _, err := resolveEtcdCredentials(config)
+		Expect(err).Should(HaveOccurred())
+	})
+})
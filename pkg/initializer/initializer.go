@@ -82,6 +82,11 @@ func (e *EtcdInitializer) Initialize(mode validator.Mode, failBelowRevision int6
 		return fmt.Errorf("error while initializing: %v", err)
 	}
 
+	if dataDirStatus == validator.MemberCountBelowExpectedError {
+		metrics.ValidationDurationSeconds.With(prometheus.Labels{metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Observe(time.Since(start).Seconds())
+		return fmt.Errorf("failed to initialize since cluster membership in data directory is below the expected member count")
+	}
+
 	if dataDirStatus == validator.FailBelowRevisionConsistencyError {
 		metrics.ValidationDurationSeconds.With(prometheus.Labels{metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Observe(time.Since(start).Seconds())
 		return fmt.Errorf("failed to initialize since fail below revision check failed")
@@ -100,7 +105,7 @@ func (e *EtcdInitializer) Initialize(mode validator.Mode, failBelowRevision int6
 		} else {
 			// For case: ClusterSize=1 or when multi-node cluster(ClusterSize>1) is bootstrapped
 			start := time.Now()
-			restored, err := e.restoreCorruptData()
+			restored, err := e.restoreCorruptData(mode)
 			if err != nil {
 				metrics.RestorationDurationSeconds.With(prometheus.Labels{metrics.LabelRestorationKind: metrics.ValueRestoreSingleNode, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Observe(time.Since(start).Seconds())
 				return fmt.Errorf("error while restoring corrupt data: %v", err)
@@ -140,13 +145,18 @@ func NewInitializer(options *brtypes.RestoreOptions, snapstoreConfig *brtypes.Sn
 
 // restoreCorruptData attempts to restore a corrupted data directory.
 // It returns true only if restoration was successful, and false when
-// bootstrapping a new data directory or if restoration failed
-func (e *EtcdInitializer) restoreCorruptData() (bool, error) {
+// bootstrapping a new data directory or if restoration failed.
+// In repair mode, the corrupt data directory is quarantined instead of
+// removed, so that it remains available for forensic inspection.
+func (e *EtcdInitializer) restoreCorruptData(mode validator.Mode) (bool, error) {
 	logger := e.Logger
 	tempRestoreOptions := *(e.Config.RestoreOptions.DeepCopy())
 	dataDir := tempRestoreOptions.Config.RestoreDataDir
 
 	if e.Config.SnapstoreConfig == nil || len(e.Config.SnapstoreConfig.Provider) == 0 {
+		if mode == validator.Repair {
+			return false, fmt.Errorf("cannot repair data directory since no snapstore storage provider is configured")
+		}
 		logger.Warnf("No snapstore storage provider configured.")
 		return e.restoreWithEmptySnapstore()
 	}
@@ -162,6 +172,9 @@ func (e *EtcdInitializer) restoreCorruptData() (bool, error) {
 		return false, err
 	}
 	if baseSnap == nil && (deltaSnapList == nil || len(deltaSnapList) == 0) {
+		if mode == validator.Repair {
+			return false, fmt.Errorf("cannot repair data directory since no valid snapshot chain exists in snapstore")
+		}
 		// Snapstore is considered to be the source of truth. Thus, if
 		// snapstore exists but is empty, data directory should be cleared.
 		logger.Infof("No snapshot found. Will remove the data directory.")
@@ -177,13 +190,18 @@ func (e *EtcdInitializer) restoreCorruptData() (bool, error) {
 	}
 
 	rs := restorer.NewRestorer(store, logrus.NewEntry(logger))
+	rs.ProgressReporter = e.ProgressReporter
 	m := member.NewMemberControl(e.Config.EtcdConnectionConfig)
 	if err := rs.RestoreAndStopEtcd(tempRestoreOptions, m); err != nil {
 		err = fmt.Errorf("failed to restore snapshot: %v", err)
 		return false, err
 	}
 
-	if err := e.removeContents(dataDir); err != nil {
+	if mode == validator.Repair {
+		if err := e.quarantineContents(dataDir); err != nil {
+			return false, fmt.Errorf("failed to quarantine corrupt contents with restored snapshot: %v", err)
+		}
+	} else if err := e.removeContents(dataDir); err != nil {
 		return false, fmt.Errorf("failed to remove corrupt contents with restored snapshot: %v", err)
 	}
 	logger.Infoln("Successfully restored the etcd data directory.")
@@ -228,6 +246,25 @@ func (e *EtcdInitializer) removeContents(dataDir string) error {
 	return nil
 }
 
+// quarantineContents moves the corrupt data directory aside to a quarantine
+// path next to it and moves the restored temporary data directory in its place.
+func (e *EtcdInitializer) quarantineContents(dataDir string) error {
+	if _, err := os.Stat(dataDir); err == nil {
+		quarantineDir := fmt.Sprintf("%s.corrupt.%d", dataDir, time.Now().Unix())
+		e.Logger.Infof("Quarantining corrupt data directory %s to %s for forensic inspection.", dataDir, quarantineDir)
+		if err := os.Rename(dataDir, quarantineDir); err != nil {
+			return fmt.Errorf("failed to quarantine corrupt data directory %s to %s with err: %v", dataDir, quarantineDir, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Rename(filepath.Join(fmt.Sprintf("%s.%s", dataDir, "part")), filepath.Join(dataDir)); err != nil {
+		return fmt.Errorf("failed to rename temp restore directory %s to data directory %s with err: %v", filepath.Join(fmt.Sprintf("%s.%s", dataDir, "part")), dataDir, err)
+	}
+	return nil
+}
+
 func (e *EtcdInitializer) removeDir(dirname string) error {
 	e.Logger.Infof("Removing directory(%s).", dirname)
 	if err := os.RemoveAll(filepath.Join(dirname)); err != nil {
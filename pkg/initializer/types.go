@@ -34,6 +34,9 @@ type EtcdInitializer struct {
 	Validator *validator.DataValidator
 	Config    *Config
 	Logger    *logrus.Logger
+	// ProgressReporter, when set, is handed to the restorer so that restoration
+	// progress can be surfaced, e.g. via the /restore/status HTTP endpoint.
+	ProgressReporter func(progress brtypes.RestoreProgress)
 }
 
 // Initializer is the interface for etcd initialization actions.
@@ -74,7 +74,7 @@ func (d *DataValidator) Validate(mode Mode, failBelowRevision int64) (DataDirSta
 		return d.checkStatus(status, err)
 	}
 
-	if mode == Full {
+	if mode == Full || mode == Repair {
 		d.Logger.Info("Checking for data directory files corruption...")
 		if err := d.checkForDataCorruption(); err != nil {
 			if errors.Is(err, bolt.ErrTimeout) {
@@ -86,6 +86,23 @@ func (d *DataValidator) Validate(mode Mode, failBelowRevision int64) (DataDirSta
 		}
 	}
 
+	if mode == Membership {
+		d.Logger.Info("Checking for cluster membership in data directory...")
+		memberCount, err := getMemberCount(d.backendPath())
+		if err != nil {
+			if errors.Is(err, bolt.ErrTimeout) {
+				d.Logger.Errorf("another etcd process is using %v and holds the file lock", d.backendPath())
+				return FailToOpenBoltDBError, err
+			}
+			d.Logger.Infof("unable to get cluster membership from backend db file: %v", err)
+			return DataDirectoryStatusUnknown, err
+		}
+		if memberCount < d.OriginalClusterSize {
+			d.Logger.Infof("member count (%d) recorded in data directory is below the expected member count (%d)", memberCount, d.OriginalClusterSize)
+			return MemberCountBelowExpectedError, nil
+		}
+	}
+
 	d.Logger.Info("Data directory valid.")
 	return DataDirectoryValid, nil
 }
@@ -463,6 +480,36 @@ func getLatestEtcdRevision(path string) (int64, error) {
 	return rev, nil
 }
 
+// getMemberCount counts the cluster members recorded in the "members" bucket of the etcd db file without starting an etcd server or an embedded etcd server.
+func getMemberCount(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, fmt.Errorf("unable to stat backend db file: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0400, &bolt.Options{Timeout: timeoutToOpenBoltDB, ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var memberCount int
+
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("members"))
+		if b == nil {
+			return fmt.Errorf("cannot get bucket \"members\"")
+		}
+		memberCount = b.Stats().KeyN
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return memberCount, nil
+}
+
 // getLatestSyncedRevision finds out the latest revision on etcd db file when embedded etcd is started to double check the latest revision of etcd db file.
 func getLatestSyncedRevision(client client.KVCloser, logger *logrus.Logger) (int64, error) {
 	var latestSyncedRevision int64
@@ -155,6 +155,22 @@ var _ = Describe("Running Datavalidator", func() {
 		})
 	})
 
+	Context("with data validation in membership mode", func() {
+		It("should return DataDirStatus as DataDirectoryValid when member count is not below the expected cluster size", func() {
+			validator.OriginalClusterSize = 1
+			dataDirStatus, err := validator.Validate(Membership, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(int(dataDirStatus)).Should(Equal(DataDirectoryValid))
+		})
+
+		It("should return DataDirStatus as MemberCountBelowExpectedError when member count is below the expected cluster size", func() {
+			validator.OriginalClusterSize = 3
+			dataDirStatus, err := validator.Validate(Membership, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(int(dataDirStatus)).Should(Equal(MemberCountBelowExpectedError))
+		})
+	})
+
 	Context("with corrupt db file", func() {
 		It("should return DataDirStatus as DataDirectoryCorrupt, and nil error", func() {
 			dbFile := path.Join(restoreDataDir, "member", "snap", "db")
@@ -191,6 +207,42 @@ var _ = Describe("Running Datavalidator", func() {
 		})
 	})
 
+	Context("with corrupt db file and data validation in repair mode", func() {
+		It("should return DataDirStatus as DataDirectoryCorrupt, and nil error", func() {
+			dbFile := path.Join(restoreDataDir, "member", "snap", "db")
+			dbFileInfo, err := os.Stat(dbFile)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			tempFile := path.Join(outputDir, "temp", "db")
+
+			err = copyFile(dbFile, tempFile, dbFileInfo.Mode())
+			Expect(err).ShouldNot(HaveOccurred())
+
+			file, err := os.OpenFile(
+				dbFile,
+				os.O_WRONLY|os.O_TRUNC|os.O_CREATE,
+				0666,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer file.Close()
+
+			// corrupt the db file by writing random data to it
+			byteSlice := []byte("Random data!\n")
+			_, err = file.Write(byteSlice)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			dataDirStatus, err := validator.Validate(Repair, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(int(dataDirStatus)).Should(Equal(DataDirectoryCorrupt))
+
+			err = os.Remove(dbFile)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = os.Rename(tempFile, dbFile)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Context("with combination of valid,corrupt,invalid name and empty snap file", func() {
 		It("should return DataDirStatus as DataDirectoryValid , and nil error", func() {
 			snapDir := path.Join(restoreDataDir, "member", "snap")
@@ -48,6 +48,8 @@ const (
 	FailBelowRevisionConsistencyError
 	// FailToOpenBoltDBError indicates that backup-restore is unable to open boltDB as it is failed to acquire lock over database.
 	FailToOpenBoltDBError
+	// MemberCountBelowExpectedError indicates the cluster membership recorded in the data directory has fewer members than expected.
+	MemberCountBelowExpectedError
 )
 
 const (
@@ -67,6 +69,12 @@ const (
 	Full Mode = "full"
 	// Sanity Mode does a quick, partial validation of data directory using time-efficient checks.
 	Sanity Mode = "sanity"
+	// Repair Mode does complete validation like Full and additionally allows the
+	// initializer to quarantine a corrupt data directory and restore it in place.
+	Repair Mode = "repair"
+	// Membership Mode does sanity validation and additionally verifies that the cluster
+	// membership recorded in the data directory is not below the expected member count.
+	Membership Mode = "membership"
 )
 
 // Config store configuration for DataValidator.
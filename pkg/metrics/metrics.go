@@ -42,12 +42,15 @@ const (
 	LabelRestorationKind = "restore"
 	// LabelEndPoint is metric label for metric of etcd cluster endpoint.
 	LabelEndPoint = "endpoint"
+	// LabelTarget is a metric label indicating the replica snapstore target associated with metric.
+	LabelTarget = "target"
 
 	namespaceEtcdBR      = "etcdbr"
 	subsystemSnapshot    = "snapshot"
 	subsystemRestore     = "restoration"
 	subsystemSnapstore   = "snapstore"
 	subsystemSnapshotter = "snapshotter"
+	subsystemGC          = "gc"
 )
 
 var (
@@ -79,6 +82,38 @@ var (
 		[]string{LabelKind, LabelSucceeded},
 	)
 
+	// GCSnapshotsDeletedTotal is metric to count the snapshots deleted by the garbage collector.
+	GCSnapshotsDeletedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceEtcdBR,
+			Subsystem: subsystemGC,
+			Name:      "snapshots_deleted_total",
+			Help:      "Total number of snapshots deleted by the garbage collector.",
+		},
+		[]string{LabelKind},
+	)
+
+	// GCDurationSeconds is metric to expose the duration of a garbage collection run in seconds.
+	GCDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespaceEtcdBR,
+			Subsystem: subsystemGC,
+			Name:      "duration_seconds",
+			Help:      "Total latency distribution of garbage collection runs.",
+		},
+		[]string{LabelSucceeded},
+	)
+
+	// SnapshotsTotal is metric to expose the current number of snapshots in the store.
+	SnapshotsTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceEtcdBR,
+			Name:      "snapshots_total",
+			Help:      "Current number of snapshots in the store.",
+		},
+		[]string{LabelKind},
+	)
+
 	// LatestSnapshotRevision is metric to expose latest snapshot revision.
 	LatestSnapshotRevision = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -174,6 +209,26 @@ var (
 		},
 		[]string{},
 	)
+	// SnapshotReplicationTotal is metric to count the snapshots replicated to the replica snapstores per target.
+	SnapshotReplicationTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceEtcdBR,
+			Subsystem: subsystemSnapstore,
+			Name:      "replication_total",
+			Help:      "Total number of snapshot replications to replica snapstores.",
+		},
+		[]string{LabelTarget, LabelSucceeded},
+	)
+	// SnapstoreListCallsTotal is metric to count the number of snapshot listing calls made to the snapstore backend.
+	SnapstoreListCallsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceEtcdBR,
+			Subsystem: subsystemSnapstore,
+			Name:      "list_calls_total",
+			Help:      "Total number of snapshot listing calls made to the snapstore backend.",
+		},
+		[]string{},
+	)
 
 	//SnapshotterOperationFailure is metric to count the number of snapshotter operations that have errored out
 	SnapshotterOperationFailure = prometheus.NewCounterVec(
@@ -186,6 +241,38 @@ var (
 		[]string{LabelError},
 	)
 
+	// SnapshotterDeltaChainLimitExceeded is metric to expose whether the delta snapshot chain since the latest full snapshot has grown beyond the configured limit.
+	SnapshotterDeltaChainLimitExceeded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceEtcdBR,
+			Subsystem: subsystemSnapshotter,
+			Name:      "delta_chain_limit_exceeded",
+			Help:      "Whether the delta snapshot chain since the latest full snapshot has grown beyond the configured limit (1) or not (0).",
+		},
+		[]string{},
+	)
+
+	// SnapshotterBreakerOpen is metric to expose whether the snapshotter circuit breaker is currently open.
+	SnapshotterBreakerOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceEtcdBR,
+			Subsystem: subsystemSnapshotter,
+			Name:      "circuit_breaker_open",
+			Help:      "Whether the snapshotter circuit breaker is currently open (1) or closed (0).",
+		},
+		[]string{},
+	)
+
+	// BackupRestorable is metric to expose whether the latest snapshot chain was restorable during the last backup validation run.
+	BackupRestorable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceEtcdBR,
+			Name:      "backup_restorable",
+			Help:      "Whether the latest snapshot chain was restorable during the last backup validation run. 1 if restorable, 0 otherwise.",
+		},
+		[]string{},
+	)
+
 	// CurrentClusterSize is metric to expose the current Etcd cluster size.
 	CurrentClusterSize = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -341,6 +428,33 @@ func init() {
 		GCSnapshotCounter.With(prometheus.Labels(combination))
 	}
 
+	// GCSnapshotsDeletedTotal
+	gcSnapshotsDeletedTotalLabelValues := map[string][]string{
+		LabelKind: labels[LabelKind],
+	}
+	gcSnapshotsDeletedTotalCombinations := generateLabelCombinations(gcSnapshotsDeletedTotalLabelValues)
+	for _, combination := range gcSnapshotsDeletedTotalCombinations {
+		GCSnapshotsDeletedTotal.With(prometheus.Labels(combination))
+	}
+
+	// GCDurationSeconds
+	gcDurationSecondsLabelValues := map[string][]string{
+		LabelSucceeded: labels[LabelSucceeded],
+	}
+	gcDurationSecondsCombinations := generateLabelCombinations(gcDurationSecondsLabelValues)
+	for _, combination := range gcDurationSecondsCombinations {
+		GCDurationSeconds.With(prometheus.Labels(combination))
+	}
+
+	// SnapshotsTotal
+	snapshotsTotalLabelValues := map[string][]string{
+		LabelKind: labels[LabelKind],
+	}
+	snapshotsTotalCombinations := generateLabelCombinations(snapshotsTotalLabelValues)
+	for _, combination := range snapshotsTotalCombinations {
+		SnapshotsTotal.With(prometheus.Labels(combination))
+	}
+
 	// LatestSnapshotRevision
 	latestSnapshotRevisionLabelValues := map[string][]string{
 		LabelKind: labels[LabelKind],
@@ -449,9 +563,18 @@ func init() {
 	// SnapstoreLatestDeltasSize
 	SnapstoreLatestDeltasRevisionsTotal.With(prometheus.Labels(map[string]string{}))
 
+	// SnapstoreListCallsTotal
+	SnapstoreListCallsTotal.With(prometheus.Labels(map[string]string{}))
+
 	//SnapshotterOperationFailure
 	SnapshotterOperationFailure.With(prometheus.Labels(map[string]string{LabelError: ""}))
 
+	//SnapshotterDeltaChainLimitExceeded
+	SnapshotterDeltaChainLimitExceeded.With(prometheus.Labels(map[string]string{}))
+
+	//SnapshotterBreakerOpen
+	SnapshotterBreakerOpen.With(prometheus.Labels(map[string]string{}))
+
 	//CurrentClusterSize
 	CurrentClusterSize.With(prometheus.Labels(map[string]string{}))
 
@@ -460,6 +583,9 @@ func init() {
 
 	// Metrics have to be registered to be exposed:
 	prometheus.MustRegister(GCSnapshotCounter)
+	prometheus.MustRegister(GCSnapshotsDeletedTotal)
+	prometheus.MustRegister(GCDurationSeconds)
+	prometheus.MustRegister(SnapshotsTotal)
 
 	prometheus.MustRegister(LatestSnapshotRevision)
 	prometheus.MustRegister(LatestSnapshotTimestamp)
@@ -472,8 +598,13 @@ func init() {
 
 	prometheus.MustRegister(SnapstoreLatestDeltasTotal)
 	prometheus.MustRegister(SnapstoreLatestDeltasRevisionsTotal)
+	prometheus.MustRegister(SnapshotReplicationTotal)
+	prometheus.MustRegister(SnapstoreListCallsTotal)
 
 	prometheus.MustRegister(SnapshotterOperationFailure)
+	prometheus.MustRegister(SnapshotterDeltaChainLimitExceeded)
+	prometheus.MustRegister(SnapshotterBreakerOpen)
+	prometheus.MustRegister(BackupRestorable)
 
 	prometheus.MustRegister(CurrentClusterSize)
 	prometheus.MustRegister(IsLearner)
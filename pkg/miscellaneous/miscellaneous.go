@@ -92,6 +92,56 @@ func GetLatestFullSnapshotAndDeltaSnapList(store brtypes.SnapStore) (*brtypes.Sn
 	return fullSnapshot, deltaSnapList, nil
 }
 
+// GetNamedFullSnapshotAndDeltaSnapList returns the full snapshot with the given
+// object name together with the named delta snapshots, bypassing the latest
+// snapshot selection. It validates that all named objects exist in the store and
+// that the delta snapshots form an unbroken chain on top of the full snapshot.
+// If no delta snapshot names are given, no deltas are replayed.
+func GetNamedFullSnapshotAndDeltaSnapList(store brtypes.SnapStore, fullSnapshotName string, deltaSnapshotNames []string) (*brtypes.Snapshot, brtypes.SnapList, error) {
+	snapList, err := store.List()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshotsByName := make(map[string]*brtypes.Snapshot, len(snapList))
+	for _, snap := range snapList {
+		if !snap.IsChunk {
+			snapshotsByName[snap.SnapName] = snap
+		}
+	}
+
+	fullSnapshot, ok := snapshotsByName[fullSnapshotName]
+	if !ok {
+		return nil, nil, fmt.Errorf("full snapshot %s not found in snapstore", fullSnapshotName)
+	}
+	if fullSnapshot.Kind != brtypes.SnapshotKindFull {
+		return nil, nil, fmt.Errorf("snapshot %s is not a full snapshot", fullSnapshotName)
+	}
+
+	var deltaSnapList brtypes.SnapList
+	for _, deltaSnapshotName := range deltaSnapshotNames {
+		deltaSnapshot, ok := snapshotsByName[deltaSnapshotName]
+		if !ok {
+			return nil, nil, fmt.Errorf("delta snapshot %s not found in snapstore", deltaSnapshotName)
+		}
+		if deltaSnapshot.Kind != brtypes.SnapshotKindDelta {
+			return nil, nil, fmt.Errorf("snapshot %s is not a delta snapshot", deltaSnapshotName)
+		}
+		deltaSnapList = append(deltaSnapList, deltaSnapshot)
+	}
+	sort.Sort(deltaSnapList)
+
+	nextStartRevision := fullSnapshot.LastRevision + 1
+	for _, deltaSnapshot := range deltaSnapList {
+		if deltaSnapshot.StartRevision != nextStartRevision {
+			return nil, nil, fmt.Errorf("broken delta snapshot chain: delta snapshot %s starts at revision %d, expected revision %d", deltaSnapshot.SnapName, deltaSnapshot.StartRevision, nextStartRevision)
+		}
+		nextStartRevision = deltaSnapshot.LastRevision + 1
+	}
+
+	return fullSnapshot, deltaSnapList, nil
+}
+
 type backup struct {
 	FullSnapshot      *brtypes.Snapshot
 	DeltaSnapshotList brtypes.SnapList
@@ -208,7 +258,7 @@ func GetFakeKubernetesClientSet() client.Client {
 func GetAllEtcdEndpoints(ctx context.Context, client etcdClient.ClusterCloser, etcdConnectionConfig *brtypes.EtcdConnectionConfig, logger *logrus.Entry) ([]string, error) {
 	var etcdEndpoints []string
 
-	ctx, cancel := context.WithTimeout(ctx, etcdConnectionConfig.ConnectionTimeout.Duration)
+	ctx, cancel := context.WithTimeout(ctx, etcdConnectionConfig.GetRequestTimeout())
 	defer cancel()
 
 	membersInfo, err := client.MemberList(ctx)
@@ -230,7 +280,7 @@ func IsEtcdClusterHealthy(ctx context.Context, client etcdClient.MaintenanceClos
 	// checks the health of all etcd members.
 	for _, endPoint := range etcdEndpoints {
 		if err := func() error {
-			ctx, cancel := context.WithTimeout(ctx, etcdConnectionConfig.ConnectionTimeout.Duration)
+			ctx, cancel := context.WithTimeout(ctx, etcdConnectionConfig.GetRequestTimeout())
 			defer cancel()
 			if _, err := client.Status(ctx, endPoint); err != nil {
 				logger.Errorf("failed to get status of etcd endPoint: %v with error: %v", endPoint, err)
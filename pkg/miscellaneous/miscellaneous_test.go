@@ -109,6 +109,63 @@ var _ = Describe("Miscellaneous Tests", func() {
 		})
 	})
 
+	Describe("Selecting named snapshots", func() {
+		var (
+			fullSnap   *brtypes.Snapshot
+			deltaSnap1 *brtypes.Snapshot
+			deltaSnap2 *brtypes.Snapshot
+		)
+		BeforeEach(func() {
+			fullSnap = &brtypes.Snapshot{
+				SnapName:      "Full-00000000-00000100-1111",
+				Kind:          brtypes.SnapshotKindFull,
+				StartRevision: 0,
+				LastRevision:  100,
+			}
+			deltaSnap1 = &brtypes.Snapshot{
+				SnapName:      "Incr-00000101-00000150-2222",
+				Kind:          brtypes.SnapshotKindDelta,
+				StartRevision: 101,
+				LastRevision:  150,
+			}
+			deltaSnap2 = &brtypes.Snapshot{
+				SnapName:      "Incr-00000151-00000200-3333",
+				Kind:          brtypes.SnapshotKindDelta,
+				StartRevision: 151,
+				LastRevision:  200,
+			}
+			ds = NewDummyStore(brtypes.SnapList{fullSnap, deltaSnap1, deltaSnap2})
+		})
+		It("should return the named full snapshot without deltas", func() {
+			baseSnap, deltaSnapList, err := GetNamedFullSnapshotAndDeltaSnapList(&ds, fullSnap.SnapName, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(baseSnap).To(Equal(fullSnap))
+			Expect(len(deltaSnapList)).To(BeZero())
+		})
+		It("should return the named full snapshot and its named deltas sorted by revision", func() {
+			baseSnap, deltaSnapList, err := GetNamedFullSnapshotAndDeltaSnapList(&ds, fullSnap.SnapName, []string{deltaSnap2.SnapName, deltaSnap1.SnapName})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(baseSnap).To(Equal(fullSnap))
+			Expect(deltaSnapList).To(Equal(brtypes.SnapList{deltaSnap1, deltaSnap2}))
+		})
+		It("should return error if the named full snapshot is not found", func() {
+			_, _, err := GetNamedFullSnapshotAndDeltaSnapList(&ds, "Full-00000000-00000100-9999", nil)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return error if a named delta snapshot is not found", func() {
+			_, _, err := GetNamedFullSnapshotAndDeltaSnapList(&ds, fullSnap.SnapName, []string{"Incr-00000101-00000150-9999"})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return error if a delta snapshot is named as full snapshot", func() {
+			_, _, err := GetNamedFullSnapshotAndDeltaSnapList(&ds, deltaSnap1.SnapName, nil)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return error if the named deltas do not form an unbroken chain", func() {
+			_, _, err := GetNamedFullSnapshotAndDeltaSnapList(&ds, fullSnap.SnapName, []string{deltaSnap2.SnapName})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("Etcd Cluster", func() {
 		var (
 			dummyID              = uint64(1111)
@@ -0,0 +1,337 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package selftest validates end-to-end backup and restore against a
+// configured snapstore. It starts an embedded etcd, populates it with test
+// data while snapshots are taken, restores the snapshots into a fresh data
+// directory and verifies key and revision consistency.
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/pkg/types"
+)
+
+const (
+	// keyPrefix is the prefix of the keys written into the embedded etcd.
+	keyPrefix = "/etcdbr/selftest/key-"
+	// valuePrefix is the prefix of the values written into the embedded etcd.
+	valuePrefix = "val-"
+	// embeddedEtcdClientPort and embeddedEtcdPeerPort are the ports the
+	// embedded etcd listens on during the self-test.
+	embeddedEtcdClientPort = "12379"
+	embeddedEtcdPeerPort   = "12380"
+	// populationDuration is how long test data is put into the embedded etcd
+	// while snapshots are taken.
+	populationDuration = 15 * time.Second
+	// deltaSnapshotPeriod is the delta snapshot period used during the self-test.
+	deltaSnapshotPeriod = time.Second
+)
+
+// populationResult captures how far the test data population got before it
+// was stopped or failed.
+type populationResult struct {
+	keyTo       int
+	endRevision int64
+	err         error
+}
+
+// Run performs one backup and restore cycle against the configured snapstore
+// and returns an error on any mismatch. All temporary state, including the
+// snapshots taken during the self-test, is cleaned up afterwards.
+func Run(ctx context.Context, logger *logrus.Entry, snapstoreConfig *brtypes.SnapstoreConfig, compressionConfig *compressor.CompressionConfig) error {
+	store, err := snapstore.GetSnapstore(snapstoreConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create snapstore from configured storage provider: %v", err)
+	}
+
+	// Snapshots are sorted by revision, so snapshots of the fresh embedded etcd
+	// would interleave with existing backups and corrupt the latest snapshot
+	// chain. Refuse to run against a non-empty destination.
+	existingSnaps, err := store.List()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots in the snapstore: %v", err)
+	}
+	if len(existingSnaps) != 0 {
+		return fmt.Errorf("the configured snapstore already contains %d snapshot(s), please run the self-test against an empty destination", len(existingSnaps))
+	}
+
+	etcdDir, err := os.MkdirTemp(snapstoreConfig.TempDir, "selftest-etcd-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary etcd data directory: %v", err)
+	}
+	defer os.RemoveAll(etcdDir)
+
+	restoreDir, err := os.MkdirTemp(snapstoreConfig.TempDir, "selftest-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary restoration directory: %v", err)
+	}
+	defer os.RemoveAll(restoreDir)
+
+	etcd, err := startEmbeddedEtcd(ctx, etcdDir, logger)
+	if err != nil {
+		return fmt.Errorf("failed to start embedded etcd: %v", err)
+	}
+	defer etcd.Close()
+	endpoints := []string{etcd.Clients[0].Addr().String()}
+
+	// Populate test data while the snapshotter takes a full and several delta
+	// snapshots; once the population is done, give the snapshotter time for a
+	// final delta snapshot before stopping it.
+	populatorCtx, cancelPopulator := context.WithTimeout(ctx, populationDuration)
+	defer cancelPopulator()
+	result := &populationResult{}
+	populatorDoneCh := make(chan struct{})
+	go func() {
+		defer close(populatorDoneCh)
+		populateEtcd(populatorCtx, logger, endpoints, result)
+	}()
+	ssrStopCh := make(chan struct{})
+	go func() {
+		<-populatorDoneCh
+		time.Sleep(2 * deltaSnapshotPeriod)
+		close(ssrStopCh)
+	}()
+	if err := runSnapshotter(logger, snapstoreConfig, compressionConfig, endpoints, ssrStopCh); err != nil {
+		return fmt.Errorf("failed to take snapshots of the test data: %v", err)
+	}
+	if result.err != nil {
+		return fmt.Errorf("failed to populate embedded etcd with test data: %v", result.err)
+	}
+	// Free the test ports for the consistency check below.
+	etcd.Close()
+
+	baseSnap, deltaSnapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest set of snapshots: %v", err)
+	}
+	if baseSnap == nil {
+		return fmt.Errorf("no snapshots found in the snapstore although snapshots were taken")
+	}
+	defer func() {
+		// Clean up the snapshots taken during the self-test.
+		for _, snap := range append(deltaSnapList, baseSnap) {
+			if err := store.Delete(*snap); err != nil {
+				logger.Warnf("Failed to delete self-test snapshot %s: %v", snap.SnapName, err)
+			}
+		}
+	}()
+
+	latestRevision := baseSnap.LastRevision
+	if len(deltaSnapList) > 0 {
+		latestRevision = deltaSnapList[len(deltaSnapList)-1].LastRevision
+	}
+	if latestRevision < result.endRevision {
+		return fmt.Errorf("snapshots cover only revision %d while test data was populated till revision %d", latestRevision, result.endRevision)
+	}
+
+	restorationConfig := brtypes.NewRestorationConfig()
+	restorationConfig.RestoreDataDir = restoreDir
+	clusterURLsMap, err := types.NewURLsMap(restorationConfig.InitialCluster)
+	if err != nil {
+		return fmt.Errorf("failed creating url map for restore cluster: %v", err)
+	}
+	peerURLs, err := types.NewURLs(restorationConfig.InitialAdvertisePeerURLs)
+	if err != nil {
+		return fmt.Errorf("failed parsing peers urls for restore cluster: %v", err)
+	}
+
+	rs := restorer.NewRestorer(store, logger)
+	if err := rs.RestoreAndStopEtcd(brtypes.RestoreOptions{
+		Config:        restorationConfig,
+		BaseSnapshot:  baseSnap,
+		DeltaSnapList: deltaSnapList,
+		ClusterURLs:   clusterURLsMap,
+		PeerURLs:      peerURLs,
+	}, nil); err != nil {
+		return fmt.Errorf("failed to restore from the taken snapshots: %v", err)
+	}
+
+	if err := checkDataConsistency(ctx, restoreDir, result.keyTo, logger); err != nil {
+		return fmt.Errorf("restored data is inconsistent with the populated test data: %v", err)
+	}
+	return nil
+}
+
+// startEmbeddedEtcd starts an embedded etcd with minimal configuration on the
+// self-test ports, serving from the given data directory.
+func startEmbeddedEtcd(ctx context.Context, etcdDir string, logger *logrus.Entry) (*embed.Etcd, error) {
+	logger.Infoln("Starting embedded etcd...")
+	cfg := embed.NewConfig()
+	cfg.Dir = etcdDir
+	cfg.EnableV2 = false
+	cfg.Debug = false
+	cfg.GRPCKeepAliveTimeout = 0
+	cfg.SnapshotCount = 10
+	lpurl, _ := url.Parse("http://localhost:" + embeddedEtcdPeerPort)
+	lcurl, _ := url.Parse("http://localhost:" + embeddedEtcdClientPort)
+	cfg.LPUrls = []url.URL{*lpurl}
+	cfg.LCUrls = []url.URL{*lcurl}
+	cfg.APUrls = []url.URL{*lpurl}
+	cfg.ACUrls = []url.URL{*lcurl}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	cfg.Logger = "zap"
+	cfg.AutoCompactionMode = "periodic"
+	cfg.AutoCompactionRetention = "0"
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	etcdWaitCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	select {
+	case <-e.Server.ReadyNotify():
+		logger.Infof("Embedded server is ready to listen client at: %s", e.Clients[0].Addr())
+	case <-etcdWaitCtx.Done():
+		e.Server.Stop() // trigger a shutdown
+		e.Close()
+		return nil, fmt.Errorf("server took too long to start")
+	}
+	return e, nil
+}
+
+// populateEtcd sequentially puts key-value pairs into the embedded etcd until
+// the context is done. Every key divisible by 10 is deleted immediately after
+// being put, so that the restored data also covers delete events.
+func populateEtcd(ctx context.Context, logger *logrus.Entry, endpoints []string, result *populationResult) {
+	result.keyTo = -1
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		result.err = fmt.Errorf("unable to start etcd client: %v", err)
+		return
+	}
+	defer cli.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("Populated data till key %s into embedded etcd with etcd end revision: %v", keyPrefix+strconv.Itoa(result.keyTo), result.endRevision)
+			return
+		case <-time.After(time.Second):
+			result.keyTo++
+			key := keyPrefix + strconv.Itoa(result.keyTo)
+			value := valuePrefix + strconv.Itoa(result.keyTo)
+			resp, err := cli.Put(ctx, key, value)
+			if err != nil {
+				result.err = fmt.Errorf("unable to put key-value pair (%s, %s) into embedded etcd: %v", key, value, err)
+				return
+			}
+			result.endRevision = resp.Header.GetRevision()
+			if result.keyTo%10 == 0 {
+				resp, err := cli.Delete(ctx, key)
+				if err != nil {
+					result.err = fmt.Errorf("unable to delete key (%s) from embedded etcd: %v", key, err)
+					return
+				}
+				result.endRevision = resp.Header.GetRevision()
+			}
+		}
+	}
+}
+
+// runSnapshotter runs a snapshotter against the embedded etcd until the stop
+// channel is closed, taking an initial full snapshot followed by periodic
+// delta snapshots.
+func runSnapshotter(logger *logrus.Entry, snapstoreConfig *brtypes.SnapstoreConfig, compressionConfig *compressor.CompressionConfig, endpoints []string, stopCh <-chan struct{}) error {
+	store, err := snapstore.GetSnapstore(snapstoreConfig)
+	if err != nil {
+		return err
+	}
+
+	etcdConnectionConfig := brtypes.NewEtcdConnectionConfig()
+	etcdConnectionConfig.ConnectionTimeout.Duration = 10 * time.Second
+	etcdConnectionConfig.Endpoints = endpoints
+
+	snapshotterConfig := &brtypes.SnapshotterConfig{
+		FullSnapshotSchedule:     "0 0 1 1 *",
+		DeltaSnapshotPeriod:      wrappers.Duration{Duration: deltaSnapshotPeriod},
+		DeltaSnapshotMemoryLimit: brtypes.DefaultDeltaSnapMemoryLimit,
+		GarbageCollectionPeriod:  wrappers.Duration{Duration: time.Minute},
+		GarbageCollectionPolicy:  brtypes.GarbageCollectionPolicyLimitBased,
+		MaxBackups:               1,
+	}
+
+	ssr, err := snapshotter.NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, brtypes.NewHealthConfig(), snapstoreConfig)
+	if err != nil {
+		return err
+	}
+
+	return ssr.Run(stopCh, true)
+}
+
+// checkDataConsistency starts an embedded etcd on the restored data directory
+// and verifies the restored key-value pairs up to the given key.
+func checkDataConsistency(ctx context.Context, dir string, keyTo int, logger *logrus.Entry) error {
+	etcd, err := startEmbeddedEtcd(ctx, dir, logger)
+	if err != nil {
+		return fmt.Errorf("unable to start embedded etcd server: %v", err)
+	}
+	defer etcd.Close()
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{etcd.Clients[0].Addr().String()},
+		DialTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to start etcd client: %v", err)
+	}
+	defer cli.Close()
+
+	for currKey := 0; currKey <= keyTo; currKey++ {
+		key := keyPrefix + strconv.Itoa(currKey)
+		value := valuePrefix + strconv.Itoa(currKey)
+
+		resp, err := cli.Get(ctx, key, clientv3.WithLimit(1))
+		if err != nil {
+			return fmt.Errorf("unable to get value from etcd: %v", err)
+		}
+		if len(resp.Kvs) == 0 {
+			// every 10th key was deleted right after being put during population
+			if currKey%10 == 0 {
+				continue
+			}
+			return fmt.Errorf("entry not found for key %s", key)
+		}
+		res := resp.Kvs[0]
+		if string(res.Key) != key {
+			return fmt.Errorf("key mismatch for %s and %s", string(res.Key), key)
+		}
+		if string(res.Value) != value {
+			return fmt.Errorf("invalid etcd data - value mismatch for %s and %s", string(res.Value), value)
+		}
+	}
+	logger.Infof("Data consistency for keys %s0 through %s%d has been verified", keyPrefix, keyPrefix, keyTo)
+
+	return nil
+}
@@ -153,15 +153,20 @@ func (b *BackupRestoreServer) startHTTPServer(initializer initializer.Initialize
 		Logger:               b.logger,
 		StopCh:               make(chan struct{}),
 		EnableProfiling:      b.config.ServerConfig.EnableProfiling,
+		AutoHealthCheck:      b.config.ServerConfig.AutoHealthCheck,
 		ReqCh:                make(chan struct{}),
 		AckCh:                make(chan struct{}),
 		EnableTLS:            b.config.ServerConfig.TLSCertFile != "" && b.config.ServerConfig.TLSKeyFile != "",
 		ServerTLSCertFile:    b.config.ServerConfig.TLSCertFile,
 		ServerTLSKeyFile:     b.config.ServerConfig.TLSKeyFile,
+		AuthBearerTokenFile:  b.config.ServerConfig.AuthBearerTokenFile,
+		AuthUsername:         b.config.ServerConfig.AuthUsername,
+		AuthPassword:         b.config.ServerConfig.AuthPassword,
 		HTTPHandlerMutex:     &sync.Mutex{},
 		EtcdConnectionConfig: etcdConfig,
 		StorageProvider:      storageProvider,
 		SnapstoreConfig:      snapstoreConfig,
+		SnapshotterConfig:    b.config.SnapshotterConfig,
 	}
 	handler.SetStatus(http.StatusServiceUnavailable)
 	b.logger.Info("Registering the http request handlers...")
@@ -190,6 +195,8 @@ func (b *BackupRestoreServer) runServer(ctx context.Context, restoreOpts *brtype
 
 	handler := b.startHTTPServer(etcdInitializer, b.config.SnapstoreConfig.Provider, b.config.EtcdConnectionConfig, b.config.SnapstoreConfig, nil)
 	defer handler.Stop()
+	// Surface restoration progress via the /restore/status endpoint.
+	etcdInitializer.ProgressReporter = handler.SetRestoreProgress
 
 	metrics.CurrentClusterSize.With(prometheus.Labels{}).Set(float64(restoreOpts.OriginalClusterSize))
 	// Promotes member if it is a learner
@@ -250,6 +257,15 @@ func (b *BackupRestoreServer) runServer(ctx context.Context, restoreOpts *brtype
 					b.logger.Fatalf("failed to create new Snapshotter object: %v", err)
 				}
 
+				if len(b.config.ReplicaSnapstoreConfig.Provider) != 0 {
+					b.logger.Infof("Creating replica snapstore from provider: %s", b.config.ReplicaSnapstoreConfig.Provider)
+					replicaStore, err := snapstore.GetSnapstore(b.config.ReplicaSnapstoreConfig)
+					if err != nil {
+						b.logger.Fatalf("failed to create replica snapstore from configured storage provider: %v", err)
+					}
+					ssr.AddReplicaSnapStores(b.config.SnapshotterConfig.FailOnSnapshotReplicationError, replicaStore)
+				}
+
 				// set "http handler" with the latest snapshotter object
 				handler.SetSnapshotter(ssr)
 				defragCallBack = ssr.TriggerFullSnapshot
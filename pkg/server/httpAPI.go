@@ -16,6 +16,7 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -27,10 +28,12 @@ import (
 	"net/http/pprof"
 	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gardener/etcd-backup-restore/pkg/etcdutil"
 	etcdclient "github.com/gardener/etcd-backup-restore/pkg/etcdutil/client"
@@ -44,6 +47,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"go.etcd.io/etcd/clientv3"
+	"golang.org/x/net/http2"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -53,6 +57,15 @@ const (
 	initializationStatusProgress   = "Progress"
 	initializationStatusSuccessful = "Successful"
 	initializationStatusFailed     = "Failed"
+
+	// defaultServerReadTimeout is the default maximum duration for reading an entire request.
+	defaultServerReadTimeout = 1 * time.Minute
+	// defaultServerWriteTimeout is the default maximum duration for writing a response. It is
+	// generous because snapshot trigger requests block until the snapshot has been saved.
+	defaultServerWriteTimeout = 15 * time.Minute
+	// defaultServerIdleTimeout is the default maximum duration to wait for the next request on
+	// a keep-alive connection.
+	defaultServerIdleTimeout = 2 * time.Minute
 )
 
 var emptyStruct struct{}
@@ -77,11 +90,16 @@ const (
 
 // HTTPHandler is implementation to handle HTTP API exposed by server
 type HTTPHandler struct {
-	Initializer               initializer.Initializer
-	Snapshotter               *snapshotter.Snapshotter
-	EtcdConnectionConfig      *brtypes.EtcdConnectionConfig
-	StorageProvider           string
-	Port                      uint
+	Initializer          initializer.Initializer
+	Snapshotter          *snapshotter.Snapshotter
+	EtcdConnectionConfig *brtypes.EtcdConnectionConfig
+	StorageProvider      string
+	Port                 uint
+	// ReadTimeout, WriteTimeout and IdleTimeout are applied to the underlying
+	// http.Server. Zero values fall back to the package defaults.
+	ReadTimeout               time.Duration
+	WriteTimeout              time.Duration
+	IdleTimeout               time.Duration
 	server                    *http.Server
 	Logger                    *logrus.Entry
 	initializationStatusMutex sync.Mutex
@@ -90,13 +108,29 @@ type HTTPHandler struct {
 	status                    int
 	StopCh                    chan struct{}
 	EnableProfiling           bool
-	ReqCh                     chan struct{}
-	AckCh                     chan struct{}
-	EnableTLS                 bool
-	ServerTLSCertFile         string
-	ServerTLSKeyFile          string
-	HTTPHandlerMutex          *sync.Mutex
-	SnapstoreConfig           *brtypes.SnapstoreConfig
+	// AutoHealthCheck additionally derives the /healthz status from the
+	// snapshotter's liveness and circuit breaker state, on top of the manually
+	// set status.
+	AutoHealthCheck   bool
+	ReqCh             chan struct{}
+	AckCh             chan struct{}
+	EnableTLS         bool
+	ServerTLSCertFile string
+	ServerTLSKeyFile  string
+	// AuthBearerTokenFile, AuthUsername and AuthPassword protect the server API
+	// beyond TLS. When any of them is configured, requests to all routes except
+	// /healthz and /metrics must carry the bearer token or the basic-auth
+	// credentials, and are rejected with status unauthorized otherwise.
+	AuthBearerTokenFile  string
+	AuthUsername         string
+	AuthPassword         string
+	HTTPHandlerMutex     *sync.Mutex
+	SnapstoreConfig      *brtypes.SnapstoreConfig
+	SnapshotterConfig    *brtypes.SnapshotterConfig
+	restoreProgressMutex sync.Mutex
+	restoreProgress      brtypes.RestoreProgress
+	finalSnapshotMutex   sync.Mutex
+	finalSnapshotTaken   bool
 }
 
 // healthCheck contains the HealthStatus of backup restore.
@@ -141,16 +175,48 @@ func (h *HTTPHandler) RegisterHandler() {
 	h.initializationStatus = "New"
 	mux.HandleFunc("/initialization/start", h.serveInitialize)
 	mux.HandleFunc("/initialization/status", h.serveInitializationStatus)
+	mux.HandleFunc("/snapshot", h.serveSnapshotPrune)
 	mux.HandleFunc("/snapshot/full", h.serveFullSnapshotTrigger)
 	mux.HandleFunc("/snapshot/delta", h.serveDeltaSnapshotTrigger)
+	mux.HandleFunc("/snapshot/final", h.serveFinalSnapshotAndStop)
 	mux.HandleFunc("/snapshot/latest", h.serveLatestSnapshotMetadata)
+	mux.HandleFunc("/snapshot/state", h.serveSnapshotterState)
+	mux.HandleFunc("/snapshot/errors", h.serveSnapshotErrors)
+	mux.HandleFunc("/snapshot/config/delta-memory-limit", h.serveDeltaMemoryLimitConfig)
+	mux.HandleFunc("/restore/status", h.serveRestoreStatus)
 	mux.HandleFunc("/config", h.serveConfig)
+	mux.HandleFunc("/config/effective", h.serveEffectiveConfig)
 	mux.HandleFunc("/healthz", h.serveHealthz)
 	mux.Handle("/metrics", promhttp.Handler())
 
+	readTimeout := h.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = defaultServerReadTimeout
+	}
+	writeTimeout := h.WriteTimeout
+	if writeTimeout <= 0 {
+		writeTimeout = defaultServerWriteTimeout
+	}
+	idleTimeout := h.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultServerIdleTimeout
+	}
+
+	var handler http.Handler = mux
+	if h.AuthBearerTokenFile != "" || h.AuthUsername != "" {
+		handler = h.authorizeRequests(mux)
+	}
+
 	h.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", h.Port),
-		Handler: mux,
+		Addr:         fmt.Sprintf(":%d", h.Port),
+		Handler:      handler,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+	// Explicitly enable HTTP/2 on the TLS listener.
+	if err := http2.ConfigureServer(h.server, &http2.Server{}); err != nil && h.Logger != nil {
+		h.Logger.Warnf("Failed to configure HTTP/2 on the http server: %v", err)
 	}
 }
 
@@ -168,6 +234,51 @@ func registerPProfHandler(mux *http.ServeMux) {
 	mux.HandleFunc("/debug/pprof/mutex", pprof.Handler("mutex").ServeHTTP)
 }
 
+// authorizeRequests wraps the given handler and rejects requests that carry
+// neither the configured bearer token nor the configured basic-auth
+// credentials. The /healthz and /metrics routes stay open deliberately so that
+// probes and metric scraping keep working without credentials.
+func (h *HTTPHandler) authorizeRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/healthz" || req.URL.Path == "/metrics" {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		if h.isAuthorized(req) {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		rw.Header().Set("WWW-Authenticate", `Basic realm="etcd-backup-restore"`)
+		rw.WriteHeader(http.StatusUnauthorized)
+	})
+}
+
+// isAuthorized reports whether the request carries the configured bearer token
+// or basic-auth credentials. The bearer token file is re-read on every request,
+// so that a rotated mounted secret is picked up without a restart.
+func (h *HTTPHandler) isAuthorized(req *http.Request) bool {
+	if h.AuthUsername != "" {
+		if username, password, ok := req.BasicAuth(); ok &&
+			subtle.ConstantTimeCompare([]byte(username), []byte(h.AuthUsername)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(h.AuthPassword)) == 1 {
+			return true
+		}
+	}
+	if h.AuthBearerTokenFile != "" {
+		data, err := os.ReadFile(h.AuthBearerTokenFile)
+		if err != nil {
+			h.Logger.Warnf("Failed to read server auth bearer token file: %v", err)
+			return false
+		}
+		token := strings.TrimSpace(string(data))
+		presented := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if token != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
 // checkAndSetSecurityHeaders serves the health status of the server
 func (h *HTTPHandler) checkAndSetSecurityHeaders(rw http.ResponseWriter) {
 	if h.EnableTLS {
@@ -206,11 +317,21 @@ func (h *HTTPHandler) Stop() error {
 // serveHealthz serves the health status of the server
 func (h *HTTPHandler) serveHealthz(rw http.ResponseWriter, req *http.Request) {
 	h.checkAndSetSecurityHeaders(rw)
-	rw.WriteHeader(h.GetStatus())
+	status := h.GetStatus()
+	if status == http.StatusOK && h.AutoHealthCheck && h.Snapshotter != nil {
+		if h.Snapshotter.IsBreakerOpen() {
+			// Report unhealthy while the snapshot circuit breaker is open so that
+			// operators notice that snapshots are being skipped.
+			status = http.StatusServiceUnavailable
+		} else if !h.Snapshotter.IsAlive() {
+			// Report unhealthy when the snapshot loop has stopped or is stuck so
+			// that the liveness probe reflects the snapshotter's actual state.
+			status = http.StatusServiceUnavailable
+		}
+	}
+	rw.WriteHeader(status)
 	healthCheck := &healthCheck{
-		HealthStatus: func() bool {
-			return h.GetStatus() == http.StatusOK
-		}(),
+		HealthStatus: status == http.StatusOK,
 	}
 	json, err := json.Marshal(healthCheck)
 	if err != nil {
@@ -261,6 +382,10 @@ func (h *HTTPHandler) serveInitialize(rw http.ResponseWriter, req *http.Request)
 				mode = validator.Full
 			case string(validator.Sanity):
 				mode = validator.Sanity
+			case string(validator.Repair):
+				mode = validator.Repair
+			case string(validator.Membership):
+				mode = validator.Membership
 			default:
 				mode = validator.Full
 			}
@@ -301,6 +426,11 @@ func (h *HTTPHandler) serveInitializationStatus(rw http.ResponseWriter, req *htt
 // for the configured Snapshotter
 func (h *HTTPHandler) serveFullSnapshotTrigger(rw http.ResponseWriter, req *http.Request) {
 	h.checkAndSetSecurityHeaders(rw)
+	if h.isFinalSnapshotTaken() {
+		h.Logger.Warnf("Refusing out-of-schedule full snapshot request as the final snapshot has been taken")
+		rw.WriteHeader(http.StatusConflict)
+		return
+	}
 	if h.Snapshotter == nil {
 		if len(h.StorageProvider) > 0 {
 			h.Logger.Info("Fowarding the request to take out-of-schedule full snapshot to backup-restore leader")
@@ -341,6 +471,11 @@ func (h *HTTPHandler) serveFullSnapshotTrigger(rw http.ResponseWriter, req *http
 // for the configured Snapshotter
 func (h *HTTPHandler) serveDeltaSnapshotTrigger(rw http.ResponseWriter, req *http.Request) {
 	h.checkAndSetSecurityHeaders(rw)
+	if h.isFinalSnapshotTaken() {
+		h.Logger.Warnf("Refusing out-of-schedule delta snapshot request as the final snapshot has been taken")
+		rw.WriteHeader(http.StatusConflict)
+		return
+	}
 	if h.Snapshotter == nil {
 		if len(h.StorageProvider) > 0 {
 			h.Logger.Info("Fowarding the request to take out-of-schedule delta snapshot to backup-restore leader")
@@ -368,6 +503,114 @@ func (h *HTTPHandler) serveDeltaSnapshotTrigger(rw http.ResponseWriter, req *htt
 	rw.Write(json)
 }
 
+// isFinalSnapshotTaken reports whether a final snapshot has been taken and the
+// snapshotter has therefore been stopped for good.
+func (h *HTTPHandler) isFinalSnapshotTaken() bool {
+	h.finalSnapshotMutex.Lock()
+	defer h.finalSnapshotMutex.Unlock()
+	return h.finalSnapshotTaken
+}
+
+// serveFinalSnapshotAndStop triggers a final full snapshot and, only upon its
+// success, stops the snapshotter for good. Subsequent snapshot trigger
+// requests are refused with a conflict status.
+func (h *HTTPHandler) serveFinalSnapshotAndStop(rw http.ResponseWriter, req *http.Request) {
+	h.checkAndSetSecurityHeaders(rw)
+	if h.isFinalSnapshotTaken() {
+		h.Logger.Warnf("Refusing final snapshot request as the final snapshot has already been taken")
+		rw.WriteHeader(http.StatusConflict)
+		return
+	}
+	if h.Snapshotter == nil {
+		if len(h.StorageProvider) > 0 {
+			h.Logger.Info("Fowarding the request to take final full snapshot to backup-restore leader")
+			h.delegateReqToLeader(rw, req)
+			return
+		}
+		h.Logger.Warnf("Ignoring final full snapshot request as snapshotter is not configured")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.Logger.Info("Received request to take a final full snapshot and stop the snapshotter.")
+	s, err := h.Snapshotter.TriggerFullSnapshot(req.Context(), true)
+	if err != nil {
+		h.Logger.Warnf("Skipped triggering final full snapshot: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Stop the snapshotter only after the final snapshot has been saved so
+	// that a failed final snapshot leaves the regular schedule untouched.
+	atomic.StoreUint32(&h.AckState, HandlerAckWaiting)
+	h.Logger.Info("Changed handler state.")
+	h.ReqCh <- emptyStruct
+	h.Logger.Info("Waiting for acknowledgment...")
+	<-h.AckCh
+
+	h.finalSnapshotMutex.Lock()
+	h.finalSnapshotTaken = true
+	h.finalSnapshotMutex.Unlock()
+
+	json, err := json.Marshal(s)
+	if err != nil {
+		h.Logger.Warnf("Unable to marshal final full snapshot to json: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(json)
+}
+
+// serveSnapshotPrune deletes all snapshots whose entire revision range lies
+// below the revision given in the beforeRevision query parameter, typically
+// after an etcd compaction. The chain needed to restore to the current state
+// is always preserved.
+func (h *HTTPHandler) serveSnapshotPrune(rw http.ResponseWriter, req *http.Request) {
+	h.checkAndSetSecurityHeaders(rw)
+	if req.Method != http.MethodDelete {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Snapshotter == nil {
+		if len(h.StorageProvider) > 0 {
+			h.Logger.Info("Fowarding the snapshot prune request to backup-restore leader")
+			h.delegateReqToLeader(rw, req)
+			return
+		}
+		h.Logger.Warnf("Ignoring snapshot prune request as snapshotter is not configured")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	beforeRevision, err := strconv.ParseInt(req.URL.Query().Get("beforeRevision"), 10, 64)
+	if err != nil || beforeRevision <= 0 {
+		h.Logger.Warnf("Could not parse request parameter 'beforeRevision' to a positive integer: %v", err)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	fullDeleted, deltaDeleted, err := h.Snapshotter.PruneSnapshotsBeforeRevision(beforeRevision)
+	if err != nil {
+		h.Logger.Warnf("Failed to prune snapshots below revision %d: %v", beforeRevision, err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(snapshotPruneResponse{
+		DeletedFullSnapshots:  fullDeleted,
+		DeletedDeltaSnapshots: deltaDeleted,
+	})
+	if err != nil {
+		h.Logger.Warnf("Unable to marshal snapshot prune response to json: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(json)
+}
+
 func (h *HTTPHandler) serveLatestSnapshotMetadata(rw http.ResponseWriter, req *http.Request) {
 	h.checkAndSetSecurityHeaders(rw)
 	if h.Snapshotter == nil {
@@ -381,9 +624,13 @@ func (h *HTTPHandler) serveLatestSnapshotMetadata(rw http.ResponseWriter, req *h
 		return
 	}
 
+	fullSnapshot, deltaSnapshots := h.Snapshotter.GetLatestSnapshotMetadata()
 	resp := latestSnapshotMetadataResponse{
-		FullSnapshot:   h.Snapshotter.PrevFullSnapshot,
-		DeltaSnapshots: h.Snapshotter.PrevDeltaSnapshots,
+		FullSnapshot:   h.locateSnapshot(fullSnapshot),
+		DeltaSnapshots: make([]*snapshotLocator, 0, len(deltaSnapshots)),
+	}
+	for _, snap := range deltaSnapshots {
+		resp.DeltaSnapshots = append(resp.DeltaSnapshots, h.locateSnapshot(snap))
 	}
 
 	json, err := json.Marshal(resp)
@@ -396,6 +643,127 @@ func (h *HTTPHandler) serveLatestSnapshotMetadata(rw http.ResponseWriter, req *h
 	rw.Write(json)
 }
 
+// locateSnapshot enriches the given snapshot with the storage locator fields
+// needed to fetch its object directly from the store.
+func (h *HTTPHandler) locateSnapshot(snap *brtypes.Snapshot) *snapshotLocator {
+	if snap == nil {
+		return nil
+	}
+	locator := &snapshotLocator{
+		Snapshot:   snap,
+		Provider:   h.StorageProvider,
+		ObjectName: path.Join(snap.Prefix, snap.SnapDir, snap.SnapName),
+	}
+	if h.SnapstoreConfig != nil {
+		locator.Container = h.SnapstoreConfig.Container
+	}
+	return locator
+}
+
+// serveSnapshotterState serves the snapshotter's current state machine phase
+// along with statistics about the snapshots taken this session.
+func (h *HTTPHandler) serveSnapshotterState(rw http.ResponseWriter, req *http.Request) {
+	h.checkAndSetSecurityHeaders(rw)
+	if h.Snapshotter == nil {
+		h.Logger.Warnf("Ignoring snapshotter state request as snapshotter is not configured")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	json, err := json.Marshal(h.Snapshotter.State())
+	if err != nil {
+		h.Logger.Warnf("Unable to marshal snapshotter state to json: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(json)
+}
+
+// serveSnapshotErrors serves the most recent snapshot and garbage collection
+// errors retained by the configured Snapshotter.
+func (h *HTTPHandler) serveSnapshotErrors(rw http.ResponseWriter, req *http.Request) {
+	h.checkAndSetSecurityHeaders(rw)
+	if h.Snapshotter == nil {
+		h.Logger.Warnf("Ignoring snapshot errors request as snapshotter is not configured")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	json, err := json.Marshal(h.Snapshotter.RecentErrors())
+	if err != nil {
+		h.Logger.Warnf("Unable to marshal snapshot errors to json: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(json)
+}
+
+// serveDeltaMemoryLimitConfig updates the delta snapshot memory limit of the
+// configured Snapshotter at runtime. The new limit takes effect on the next
+// delta accumulation cycle.
+func (h *HTTPHandler) serveDeltaMemoryLimitConfig(rw http.ResponseWriter, req *http.Request) {
+	h.checkAndSetSecurityHeaders(rw)
+	if req.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Snapshotter == nil {
+		h.Logger.Warnf("Ignoring delta snapshot memory limit update request as snapshotter is not configured")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	limitConfig := struct {
+		DeltaSnapshotMemoryLimit uint `json:"deltaSnapshotMemoryLimit"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&limitConfig); err != nil {
+		h.Logger.Warnf("Unable to decode delta snapshot memory limit request: %v", err)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := h.Snapshotter.SetDeltaSnapshotMemoryLimit(limitConfig.DeltaSnapshotMemoryLimit); err != nil {
+		h.Logger.Warnf("Unable to update delta snapshot memory limit: %v", err)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	h.Logger.Infof("Updated delta snapshot memory limit to %d bytes", limitConfig.DeltaSnapshotMemoryLimit)
+	rw.WriteHeader(http.StatusOK)
+}
+
+// SetRestoreProgress records the latest restoration progress reported by the
+// restorer so that it can be served via the /restore/status endpoint.
+func (h *HTTPHandler) SetRestoreProgress(progress brtypes.RestoreProgress) {
+	h.restoreProgressMutex.Lock()
+	defer h.restoreProgressMutex.Unlock()
+	h.restoreProgress = progress
+}
+
+// serveRestoreStatus serves the progress of the latest restoration, or the New
+// phase if no restoration has been started since the server came up.
+func (h *HTTPHandler) serveRestoreStatus(rw http.ResponseWriter, req *http.Request) {
+	h.checkAndSetSecurityHeaders(rw)
+	h.restoreProgressMutex.Lock()
+	progress := h.restoreProgress
+	h.restoreProgressMutex.Unlock()
+	if len(progress.Phase) == 0 {
+		progress.Phase = brtypes.RestorePhaseNew
+	}
+
+	json, err := json.Marshal(progress)
+	if err != nil {
+		h.Logger.Warnf("Unable to marshal restore progress to json: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(json)
+}
+
 func (h *HTTPHandler) serveConfig(rw http.ResponseWriter, req *http.Request) {
 	inputFileName := miscellaneous.EtcdConfigFilePath
 	outputFileName := "/etc/etcd.conf.yaml"
@@ -480,6 +848,48 @@ func (h *HTTPHandler) serveConfig(rw http.ResponseWriter, req *http.Request) {
 	h.Logger.Info("Served config for ETCD instance.")
 }
 
+// serveEffectiveConfig serves the effective non-secret configuration the server
+// is running with. The /config route is already taken by the tailored etcd
+// configuration, hence this is served under /config/effective. Credentials are
+// redacted and only file paths, never certificate or key contents, are included.
+func (h *HTTPHandler) serveEffectiveConfig(rw http.ResponseWriter, req *http.Request) {
+	h.checkAndSetSecurityHeaders(rw)
+	if req.Method != http.MethodGet {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := effectiveConfigResponse{
+		ServerPort:      h.Port,
+		TLSEnabled:      h.EnableTLS,
+		StorageProvider: h.StorageProvider,
+	}
+
+	if h.EtcdConnectionConfig != nil {
+		// copy the connection config and redact the credentials before serving it
+		etcdConnection := *h.EtcdConnectionConfig
+		etcdConnection.Username = ""
+		etcdConnection.Password = ""
+		resp.EtcdConnection = &etcdConnection
+	}
+
+	// snapshotter related configuration is only served when a snapshotter is configured
+	if h.Snapshotter != nil {
+		resp.Snapstore = h.SnapstoreConfig
+		resp.Snapshotter = h.SnapshotterConfig
+	}
+
+	json, err := json.Marshal(resp)
+	if err != nil {
+		h.Logger.Warnf("Unable to marshal effective configuration to json: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(json)
+}
+
 // GetClusterState returns the Cluster state either `new` or `existing`.
 func (h *HTTPHandler) GetClusterState(ctx context.Context, clusterSize int, client client.Client, podName string, podNS string) (string, error) {
 	if clusterSize == 1 {
@@ -15,10 +15,21 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/sirupsen/logrus"
 )
 
 func TestHealthCheckHandler(t *testing.T) {
@@ -35,6 +46,258 @@ func TestHealthCheckHandler(t *testing.T) {
 	}
 }
 
+func TestServeEffectiveConfig(t *testing.T) {
+	etcdConnectionConfig := brtypes.NewEtcdConnectionConfig()
+	etcdConnectionConfig.Username = "admin"
+	etcdConnectionConfig.Password = "top-secret"
+
+	handler := HTTPHandler{
+		Logger:               logrus.NewEntry(logrus.New()),
+		Port:                 8080,
+		StorageProvider:      "S3",
+		EtcdConnectionConfig: etcdConnectionConfig,
+	}
+
+	req, err := http.NewRequest("GET", "/config/effective", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.serveEffectiveConfig(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if strings.Contains(rr.Body.String(), "top-secret") {
+		t.Fatalf("effective configuration must not contain credentials: %v", rr.Body.String())
+	}
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal effective configuration: %v", err)
+	}
+	if resp["storageProvider"] != "S3" {
+		t.Fatalf("effective configuration returned wrong storage provider: got %v want S3", resp["storageProvider"])
+	}
+	if _, ok := resp["snapshotter"]; ok {
+		t.Fatalf("effective configuration must not contain snapshotter fields when no snapshotter is configured: %v", rr.Body.String())
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := HTTPHandler{
+		Logger:              logrus.NewEntry(logrus.New()),
+		AuthBearerTokenFile: tokenFile,
+		AuthUsername:        "admin",
+		AuthPassword:        "pass",
+	}
+	handler.SetStatus(http.StatusOK)
+	handler.RegisterHandler()
+
+	testCases := []struct {
+		name       string
+		path       string
+		authorize  func(req *http.Request)
+		wantStatus int
+	}{
+		{"no credentials", "/restore/status", func(req *http.Request) {}, http.StatusUnauthorized},
+		{"wrong basic auth credentials", "/restore/status", func(req *http.Request) { req.SetBasicAuth("admin", "wrong") }, http.StatusUnauthorized},
+		{"wrong bearer token", "/restore/status", func(req *http.Request) { req.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+		{"correct basic auth credentials", "/restore/status", func(req *http.Request) { req.SetBasicAuth("admin", "pass") }, http.StatusOK},
+		{"correct bearer token", "/restore/status", func(req *http.Request) { req.Header.Set("Authorization", "Bearer secret-token") }, http.StatusOK},
+		{"healthz without credentials", "/healthz", func(req *http.Request) {}, http.StatusOK},
+		{"metrics without credentials", "/metrics", func(req *http.Request) {}, http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req, err := http.NewRequest("GET", tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tc.authorize(req)
+		rr := httptest.NewRecorder()
+		handler.server.Handler.ServeHTTP(rr, req)
+		if rr.Code != tc.wantStatus {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", tc.name, rr.Code, tc.wantStatus)
+		}
+	}
+
+	// without any auth configuration the API must stay open
+	openHandler := HTTPHandler{
+		Logger: logrus.NewEntry(logrus.New()),
+	}
+	openHandler.SetStatus(http.StatusOK)
+	openHandler.RegisterHandler()
+	req, err := http.NewRequest("GET", "/restore/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	openHandler.server.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("handler without auth configuration returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestServeRestoreStatus(t *testing.T) {
+	handler := HTTPHandler{
+		Logger: logrus.NewEntry(logrus.New()),
+	}
+
+	req, err := http.NewRequest("GET", "/restore/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.serveRestoreStatus(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var progress brtypes.RestoreProgress
+	if err := json.Unmarshal(rr.Body.Bytes(), &progress); err != nil {
+		t.Fatalf("failed to unmarshal restore progress: %v", err)
+	}
+	if progress.Phase != brtypes.RestorePhaseNew {
+		t.Fatalf("restore status phase: got %v want %v", progress.Phase, brtypes.RestorePhaseNew)
+	}
+
+	handler.SetRestoreProgress(brtypes.RestoreProgress{
+		Phase:           brtypes.RestorePhaseApplying,
+		CurrentSnapshot: "Incr-00000001-00000010-1234567890",
+		DeltasApplied:   2,
+		DeltasTotal:     5,
+		CurrentRevision: 10,
+	})
+	rr = httptest.NewRecorder()
+	handler.serveRestoreStatus(rr, req)
+
+	if err := json.Unmarshal(rr.Body.Bytes(), &progress); err != nil {
+		t.Fatalf("failed to unmarshal restore progress: %v", err)
+	}
+	if progress.Phase != brtypes.RestorePhaseApplying {
+		t.Fatalf("restore status phase: got %v want %v", progress.Phase, brtypes.RestorePhaseApplying)
+	}
+	if progress.DeltasApplied != 2 || progress.DeltasTotal != 5 || progress.CurrentRevision != 10 {
+		t.Fatalf("restore status returned unexpected progress: %+v", progress)
+	}
+}
+
+func TestServeLatestSnapshotMetadata(t *testing.T) {
+	snapstoreConfig := &brtypes.SnapstoreConfig{Container: t.TempDir(), Provider: brtypes.SnapstoreProviderLocal}
+	store, err := snapstore.GetSnapstore(snapstoreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssr, err := snapshotter.NewSnapshotter(logrus.NewEntry(logrus.New()), snapshotter.NewSnapshotterConfig(), store, brtypes.NewEtcdConnectionConfig(), compressor.NewCompressorConfig(), brtypes.NewHealthConfig(), snapstoreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssr.PrevFullSnapshot = snapstore.NewSnapshot(brtypes.SnapshotKindFull, 0, 10, "", false)
+	ssr.PrevDeltaSnapshots = brtypes.SnapList{snapstore.NewSnapshot(brtypes.SnapshotKindDelta, 11, 20, "", false)}
+
+	handler := HTTPHandler{
+		Logger:          logrus.NewEntry(logrus.New()),
+		Snapshotter:     ssr,
+		StorageProvider: brtypes.SnapstoreProviderLocal,
+		SnapstoreConfig: snapstoreConfig,
+	}
+
+	req, err := http.NewRequest("GET", "/snapshot/latest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.serveLatestSnapshotMetadata(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var resp latestSnapshotMetadataResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal latest snapshot metadata: %v", err)
+	}
+	if resp.FullSnapshot == nil || len(resp.DeltaSnapshots) != 1 {
+		t.Fatalf("latest snapshot metadata misses snapshots: %v", rr.Body.String())
+	}
+	for _, locator := range []*snapshotLocator{resp.FullSnapshot, resp.DeltaSnapshots[0]} {
+		if locator.Provider != brtypes.SnapstoreProviderLocal {
+			t.Fatalf("snapshot locator returned wrong provider: got %v want %v", locator.Provider, brtypes.SnapstoreProviderLocal)
+		}
+		if locator.Container != snapstoreConfig.Container {
+			t.Fatalf("snapshot locator returned wrong container: got %v want %v", locator.Container, snapstoreConfig.Container)
+		}
+		if !strings.Contains(locator.ObjectName, locator.SnapName) {
+			t.Fatalf("snapshot locator object name %v does not contain the snapshot name %v", locator.ObjectName, locator.SnapName)
+		}
+	}
+}
+
+func TestSnapshotTriggersRefusedAfterFinalSnapshot(t *testing.T) {
+	handler := HTTPHandler{
+		Logger:             logrus.NewEntry(logrus.New()),
+		finalSnapshotTaken: true,
+	}
+
+	for _, route := range []struct {
+		path      string
+		handrFunc http.HandlerFunc
+	}{
+		{"/snapshot/full", handler.serveFullSnapshotTrigger},
+		{"/snapshot/delta", handler.serveDeltaSnapshotTrigger},
+		{"/snapshot/final", handler.serveFinalSnapshotAndStop},
+	} {
+		req, err := http.NewRequest("GET", route.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		route.handrFunc(rr, req)
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("handler for %s returned wrong status code after final snapshot: got %v want %v", route.path, rr.Code, http.StatusConflict)
+		}
+	}
+}
+
+func TestRegisterHandlerTimeouts(t *testing.T) {
+	handler := HTTPHandler{
+		Logger: logrus.NewEntry(logrus.New()),
+		Port:   8080,
+	}
+	handler.RegisterHandler()
+	if handler.server.ReadTimeout != defaultServerReadTimeout {
+		t.Fatalf("server read timeout: got %v want %v", handler.server.ReadTimeout, defaultServerReadTimeout)
+	}
+	if handler.server.WriteTimeout != defaultServerWriteTimeout {
+		t.Fatalf("server write timeout: got %v want %v", handler.server.WriteTimeout, defaultServerWriteTimeout)
+	}
+	if handler.server.IdleTimeout != defaultServerIdleTimeout {
+		t.Fatalf("server idle timeout: got %v want %v", handler.server.IdleTimeout, defaultServerIdleTimeout)
+	}
+
+	handler = HTTPHandler{
+		Logger:       logrus.NewEntry(logrus.New()),
+		Port:         8080,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Minute,
+		IdleTimeout:  time.Minute,
+	}
+	handler.RegisterHandler()
+	if handler.server.ReadTimeout != 10*time.Second {
+		t.Fatalf("server read timeout: got %v want %v", handler.server.ReadTimeout, 10*time.Second)
+	}
+	if handler.server.WriteTimeout != 30*time.Minute {
+		t.Fatalf("server write timeout: got %v want %v", handler.server.WriteTimeout, 30*time.Minute)
+	}
+	if handler.server.IdleTimeout != time.Minute {
+		t.Fatalf("server idle timeout: got %v want %v", handler.server.IdleTimeout, time.Minute)
+	}
+}
+
 func healthCheckTest(handlerFunc http.HandlerFunc, expectedStatus int, expectedHealth bool) error {
 	// Create a request to pass to our handler. We don't have any query parameters for now, so we'll
 	// pass 'nil' as the third parameter.
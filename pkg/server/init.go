@@ -34,6 +34,7 @@ func NewBackupRestoreComponentConfig() *BackupRestoreComponentConfig {
 		ServerConfig:             NewHTTPServerConfig(),
 		SnapshotterConfig:        snapshotter.NewSnapshotterConfig(),
 		SnapstoreConfig:          snapstore.NewSnapstoreConfig(),
+		ReplicaSnapstoreConfig:   snapstore.NewSnapstoreConfig(),
 		CompressionConfig:        compressor.NewCompressorConfig(),
 		RestorationConfig:        brtypes.NewRestorationConfig(),
 		OwnerCheckConfig:         brtypes.NewOwnerCheckConfig(),
@@ -51,6 +52,7 @@ func (c *BackupRestoreComponentConfig) AddFlags(fs *flag.FlagSet) {
 	c.ServerConfig.AddFlags(fs)
 	c.SnapshotterConfig.AddFlags(fs)
 	c.SnapstoreConfig.AddFlags(fs)
+	c.ReplicaSnapstoreConfig.AddReplicaFlags(fs)
 	c.RestorationConfig.AddFlags(fs)
 	c.CompressionConfig.AddFlags(fs)
 	c.OwnerCheckConfig.AddFlags(fs)
@@ -77,6 +79,11 @@ func (c *BackupRestoreComponentConfig) Validate() error {
 	if err := c.SnapstoreConfig.Validate(); err != nil {
 		return err
 	}
+	if len(c.ReplicaSnapstoreConfig.Provider) != 0 {
+		if err := c.ReplicaSnapstoreConfig.Validate(); err != nil {
+			return err
+		}
+	}
 	if err := c.RestorationConfig.Validate(); err != nil {
 		return err
 	}
@@ -104,14 +111,30 @@ func (c *BackupRestoreComponentConfig) Validate() error {
 // Complete completes the config.
 func (c *BackupRestoreComponentConfig) Complete() {
 	c.SnapstoreConfig.Complete()
+	if len(c.ReplicaSnapstoreConfig.Provider) != 0 {
+		c.ReplicaSnapstoreConfig.Complete()
+	}
 }
 
 // HTTPServerConfig holds the server config.
 type HTTPServerConfig struct {
-	Port            uint   `json:"port,omitempty"`
-	EnableProfiling bool   `json:"enableProfiling,omitempty"`
+	Port            uint `json:"port,omitempty"`
+	EnableProfiling bool `json:"enableProfiling,omitempty"`
+	// AutoHealthCheck additionally derives the /healthz status from the
+	// snapshotter's liveness and circuit breaker state, on top of the manually
+	// set status.
+	AutoHealthCheck bool   `json:"autoHealthCheck,omitempty"`
 	TLSCertFile     string `json:"server-cert,omitempty"`
 	TLSKeyFile      string `json:"server-key,omitempty"`
+	// AuthBearerTokenFile is the path to a file holding a bearer token that
+	// clients must present to call the server API. The file is re-read on every
+	// request, so that a rotated mounted secret is picked up without a restart.
+	AuthBearerTokenFile string `json:"authBearerTokenFile,omitempty"`
+	// AuthUsername and AuthPassword enable HTTP basic auth on the server API.
+	// The /healthz and /metrics routes deliberately stay open for probes and
+	// scraping, irrespective of the configured credentials.
+	AuthUsername string `json:"authUsername,omitempty"`
+	AuthPassword string `json:"authPassword,omitempty"`
 }
 
 // NewHTTPServerConfig returns the config for http server
@@ -126,8 +149,12 @@ func NewHTTPServerConfig() *HTTPServerConfig {
 func (c *HTTPServerConfig) AddFlags(fs *flag.FlagSet) {
 	fs.UintVarP(&c.Port, "server-port", "p", c.Port, "port on which server should listen")
 	fs.BoolVar(&c.EnableProfiling, "enable-profiling", c.EnableProfiling, "enable profiling")
+	fs.BoolVar(&c.AutoHealthCheck, "auto-health-check", c.AutoHealthCheck, "additionally derive the health check status from the snapshotter's liveness and circuit breaker state")
 	fs.StringVar(&c.TLSCertFile, "server-cert", "", "TLS certificate file for backup-restore server")
 	fs.StringVar(&c.TLSKeyFile, "server-key", "", "TLS key file for backup-restore server")
+	fs.StringVar(&c.AuthBearerTokenFile, "server-auth-bearer-token-file", c.AuthBearerTokenFile, "path to a file holding the bearer token required to call the backup-restore server API")
+	fs.StringVar(&c.AuthUsername, "server-auth-username", c.AuthUsername, "username required to call the backup-restore server API via basic auth")
+	fs.StringVar(&c.AuthPassword, "server-auth-password", c.AuthPassword, "password required to call the backup-restore server API via basic auth")
 }
 
 // Validate validates the config.E
@@ -142,5 +169,13 @@ func (c *HTTPServerConfig) Validate() error {
 			return fmt.Errorf("TLS enabled but server TLS key file is invalid. Will not start HTTPS server: %v", err)
 		}
 	}
+	if (c.AuthUsername == "") != (c.AuthPassword == "") {
+		return fmt.Errorf("server auth username and password must be configured together")
+	}
+	if c.AuthBearerTokenFile != "" {
+		if _, err := os.Stat(c.AuthBearerTokenFile); err != nil {
+			return fmt.Errorf("server auth bearer token file is invalid: %v", err)
+		}
+	}
 	return nil
 }
@@ -31,6 +31,7 @@ type BackupRestoreComponentConfig struct {
 	ServerConfig             *HTTPServerConfig                 `json:"serverConfig,omitempty"`
 	SnapshotterConfig        *brtypes.SnapshotterConfig        `json:"snapshotterConfig,omitempty"`
 	SnapstoreConfig          *brtypes.SnapstoreConfig          `json:"snapstoreConfig,omitempty"`
+	ReplicaSnapstoreConfig   *brtypes.SnapstoreConfig          `json:"replicaSnapstoreConfig,omitempty"`
 	CompressionConfig        *compressor.CompressionConfig     `json:"compressionConfig,omitempty"`
 	RestorationConfig        *brtypes.RestorationConfig        `json:"restorationConfig,omitempty"`
 	OwnerCheckConfig         *brtypes.OwnerCheckConfig         `json:"ownerCheckConfig,omitempty"`
@@ -41,8 +42,35 @@ type BackupRestoreComponentConfig struct {
 	ExponentialBackoffConfig *brtypes.ExponentialBackoffConfig `json:"exponentialBackoffConfig,omitempty"`
 }
 
+// snapshotLocator pairs a snapshot with the storage locator fields needed to
+// fetch its object directly from the store. Only locator fields are exposed,
+// never credentials.
+type snapshotLocator struct {
+	*brtypes.Snapshot
+	Provider   string `json:"provider,omitempty"`
+	Container  string `json:"container,omitempty"`
+	ObjectName string `json:"objectName,omitempty"`
+}
+
 // latestSnapshotMetadata holds snapshot details of latest full and delta snapshots
 type latestSnapshotMetadataResponse struct {
-	FullSnapshot   *brtypes.Snapshot `json:"fullSnapshot"`
-	DeltaSnapshots brtypes.SnapList  `json:"deltaSnapshots"`
+	FullSnapshot   *snapshotLocator   `json:"fullSnapshot"`
+	DeltaSnapshots []*snapshotLocator `json:"deltaSnapshots"`
+}
+
+// snapshotPruneResponse reports how many snapshots a prune request removed.
+type snapshotPruneResponse struct {
+	DeletedFullSnapshots  int `json:"deletedFullSnapshots"`
+	DeletedDeltaSnapshots int `json:"deletedDeltaSnapshots"`
+}
+
+// effectiveConfigResponse holds the effective non-secret configuration the server
+// is running with. Credentials are redacted before it is served.
+type effectiveConfigResponse struct {
+	ServerPort      uint                          `json:"serverPort"`
+	TLSEnabled      bool                          `json:"tlsEnabled"`
+	StorageProvider string                        `json:"storageProvider,omitempty"`
+	EtcdConnection  *brtypes.EtcdConnectionConfig `json:"etcdConnection,omitempty"`
+	Snapstore       *brtypes.SnapstoreConfig      `json:"snapstore,omitempty"`
+	Snapshotter     *brtypes.SnapshotterConfig    `json:"snapshotter,omitempty"`
 }
@@ -0,0 +1,35 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restorer
+
+import "errors"
+
+// Sentinel errors wrapped into the errors returned by the Restorer, so that
+// callers can classify restoration failures with errors.Is and decide whether
+// to retry without parsing error messages.
+var (
+	// ErrNoSnapshotsFound is returned when the snapstore holds no base snapshot
+	// to restore from.
+	ErrNoSnapshotsFound = errors.New("no snapshots found")
+	// ErrCorruptSnapshot is returned when a snapshot payload fails its
+	// integrity check, e.g. a missing or mismatched sha256 hash.
+	ErrCorruptSnapshot = errors.New("corrupt snapshot")
+	// ErrDeltaGap is returned when the delta snapshot chain does not line up
+	// revision-wise, i.e. a delta snapshot in between is missing.
+	ErrDeltaGap = errors.New("gap in delta snapshot chain")
+	// ErrEtcdReplay is returned when applying snapshot events to the embedded
+	// etcd fails or leaves it at an unexpected revision.
+	ErrEtcdReplay = errors.New("etcd replay failed")
+)
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restorer
+
+import (
+	"fmt"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	"go.etcd.io/etcd/embed"
+)
+
+// GetEmbedConfigForExistingCluster returns an embed config for the restored
+// member to join the existing cluster described by the restoration config as a
+// learner, instead of bootstrapping a fresh single-member cluster. The
+// restoration config must name this member and list the peer URLs of all
+// existing members plus this member in InitialCluster. The member must have
+// been registered with the cluster as a learner, e.g. via
+// member.Control.AddMemberAsLearner, before etcd is started with the returned
+// config; it then synchronises from the leader and can later be promoted to a
+// voting member.
+func (r *Restorer) GetEmbedConfigForExistingCluster(ro brtypes.RestoreOptions) (*embed.Config, error) {
+	if !ro.Config.JoinExistingClusterAsLearner {
+		return nil, fmt.Errorf("restoration config is not set to join an existing cluster")
+	}
+	if _, ok := ro.ClusterURLs[ro.Config.Name]; !ok {
+		return nil, fmt.Errorf("initial cluster %s does not contain this member %s", ro.Config.InitialCluster, ro.Config.Name)
+	}
+
+	cfg := embed.NewConfig()
+	cfg.Name = ro.Config.Name
+	cfg.Dir = ro.Config.RestoreDataDir
+	cfg.InitialCluster = ro.Config.InitialCluster
+	cfg.InitialClusterToken = ro.Config.InitialClusterToken
+	cfg.ClusterState = embed.ClusterStateFlagExisting
+	cfg.LPUrls = ro.PeerURLs
+	cfg.APUrls = ro.PeerURLs
+	cfg.QuotaBackendBytes = ro.Config.EmbeddedEtcdQuotaBytes
+	cfg.MaxRequestBytes = ro.Config.MaxRequestBytes
+	cfg.MaxTxnOps = ro.Config.MaxTxnOps
+	cfg.AutoCompactionMode = ro.Config.AutoCompactionMode
+	cfg.AutoCompactionRetention = ro.Config.AutoCompactionRetention
+	cfg.Logger = "zap"
+	return cfg, nil
+}
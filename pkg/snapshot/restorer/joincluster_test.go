@@ -0,0 +1,80 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restorer_test
+
+import (
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/pkg/types"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Joining an existing cluster as a learner", func() {
+	var (
+		restoreOptions brtypes.RestoreOptions
+		rstr           *Restorer
+	)
+
+	BeforeEach(func() {
+		store, err := snapstore.GetSnapstore(&brtypes.SnapstoreConfig{Container: snapstoreDir, Provider: "Local"})
+		Expect(err).ShouldNot(HaveOccurred())
+		rstr = NewRestorer(store, logger)
+
+		restorationConfig := brtypes.NewRestorationConfig()
+		restorationConfig.Name = "member-1"
+		restorationConfig.RestoreDataDir = etcdDir
+		restorationConfig.InitialCluster = "member-0=http://localhost:2380,member-1=http://localhost:2381,member-2=http://localhost:2382"
+		restorationConfig.InitialAdvertisePeerURLs = []string{"http://localhost:2381"}
+		restorationConfig.JoinExistingClusterAsLearner = true
+
+		clusterURLsMap, err := types.NewURLsMap(restorationConfig.InitialCluster)
+		Expect(err).ShouldNot(HaveOccurred())
+		peerURLs, err := types.NewURLs(restorationConfig.InitialAdvertisePeerURLs)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		restoreOptions = brtypes.RestoreOptions{
+			Config:      restorationConfig,
+			ClusterURLs: clusterURLsMap,
+			PeerURLs:    peerURLs,
+		}
+	})
+
+	It("should generate an embed config that joins the existing cluster", func() {
+		cfg, err := rstr.GetEmbedConfigForExistingCluster(restoreOptions)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cfg.Name).Should(Equal("member-1"))
+		Expect(cfg.Dir).Should(Equal(etcdDir))
+		Expect(cfg.ClusterState).Should(Equal(embed.ClusterStateFlagExisting))
+		Expect(cfg.InitialCluster).Should(Equal(restoreOptions.Config.InitialCluster))
+		Expect(cfg.APUrls).Should(HaveLen(1))
+		Expect(cfg.APUrls[0].String()).Should(Equal("http://localhost:2381"))
+	})
+
+	It("should fail when the config is not set to join an existing cluster", func() {
+		restoreOptions.Config.JoinExistingClusterAsLearner = false
+		_, err := rstr.GetEmbedConfigForExistingCluster(restoreOptions)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should fail when the member is missing from the initial cluster", func() {
+		restoreOptions.Config.Name = "member-3"
+		_, err := rstr.GetEmbedConfigForExistingCluster(restoreOptions)
+		Expect(err).Should(HaveOccurred())
+	})
+})
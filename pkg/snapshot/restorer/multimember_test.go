@@ -0,0 +1,127 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restorer_test
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/etcdserver/api/membership"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.etcd.io/etcd/pkg/types"
+	"go.etcd.io/etcd/raft/raftpb"
+	"go.etcd.io/etcd/wal"
+	"go.etcd.io/etcd/wal/walpb"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Restoring as a member of an existing cluster", func() {
+	const (
+		restoreClusterToken = "etcd-cluster"
+		restoreCluster      = "member-1=http://localhost:2380,member-2=http://localhost:2381,member-3=http://localhost:2382"
+		restoreName         = "member-1"
+	)
+	var (
+		restoreDataDir string
+		clusterUrlsMap types.URLsMap
+		peerUrls       types.URLs
+		deltaSnapList  brtypes.SnapList
+		restoreOpts    brtypes.RestoreOptions
+		rstr           *Restorer
+	)
+
+	BeforeEach(func() {
+		restoreDataDir = path.Join(outputDir, "multimember_etcd")
+		err = os.RemoveAll(restoreDataDir)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		clusterUrlsMap, err = types.NewURLsMap(restoreCluster)
+		Expect(err).ShouldNot(HaveOccurred())
+		peerUrls, err = types.NewURLs([]string{"http://localhost:2380"})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		store, err := snapstore.GetSnapstore(&brtypes.SnapstoreConfig{Container: snapstoreDir, Provider: "Local"})
+		Expect(err).ShouldNot(HaveOccurred())
+		baseSnapshot, snapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+		Expect(err).ShouldNot(HaveOccurred())
+		deltaSnapList = snapList
+
+		rstr = NewRestorer(store, logger)
+		restoreOpts = brtypes.RestoreOptions{
+			Config: &brtypes.RestorationConfig{
+				RestoreDataDir:           restoreDataDir,
+				InitialClusterToken:      restoreClusterToken,
+				InitialCluster:           restoreCluster,
+				Name:                     restoreName,
+				InitialAdvertisePeerURLs: []string{"http://localhost:2380"},
+				MaxFetchers:              6,
+				EmbeddedEtcdQuotaBytes:   8 * 1024 * 1024 * 1024,
+				AutoCompactionMode:       "periodic",
+				AutoCompactionRetention:  "0",
+			},
+			BaseSnapshot: baseSnapshot,
+			ClusterURLs:  clusterUrlsMap,
+			PeerURLs:     peerUrls,
+		}
+	})
+
+	It("should bake the full existing cluster membership into the member metadata", func() {
+		err = rstr.RestoreAndStopEtcd(restoreOpts, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		cl, err := membership.NewClusterFromURLsMap(zap.NewNop(), restoreClusterToken, clusterUrlsMap)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		w, err := wal.Open(zap.NewNop(), filepath.Join(restoreDataDir, "member", "wal"), walpb.Snapshot{})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer w.Close()
+		metadata, _, entries, err := w.ReadAll()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var md etcdserverpb.Metadata
+		Expect(md.Unmarshal(metadata)).ShouldNot(HaveOccurred())
+		Expect(md.NodeID).Should(Equal(uint64(cl.MemberByName(restoreName).ID)))
+		Expect(md.ClusterID).Should(Equal(uint64(cl.ID())))
+
+		addedMembers := 0
+		for _, entry := range entries {
+			if entry.Type != raftpb.EntryConfChange {
+				continue
+			}
+			var cc raftpb.ConfChange
+			Expect(cc.Unmarshal(entry.Data)).ShouldNot(HaveOccurred())
+			if cc.Type == raftpb.ConfChangeAddNode {
+				addedMembers++
+			}
+		}
+		Expect(addedMembers).Should(Equal(len(clusterUrlsMap)))
+	})
+
+	It("should refuse to apply delta snapshots for a multi-member restoration", func() {
+		restoreOpts.DeltaSnapList = deltaSnapList
+
+		err = rstr.RestoreAndStopEtcd(restoreOpts, nil)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("multi-member"))
+	})
+})
@@ -15,6 +15,7 @@
 package restorer
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/sha256"
@@ -27,13 +28,16 @@ import (
 	"path/filepath"
 	"reflect"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/deltacodec"
 	"github.com/gardener/etcd-backup-restore/pkg/etcdutil"
 	"github.com/gardener/etcd-backup-restore/pkg/etcdutil/client"
 	"github.com/gardener/etcd-backup-restore/pkg/member"
 	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
 	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
 	"github.com/sirupsen/logrus"
 	"go.etcd.io/etcd/clientv3"
@@ -66,15 +70,25 @@ type Restorer struct {
 	logger    *logrus.Entry
 	zapLogger *zap.Logger
 	store     brtypes.SnapStore
+	// fetchStore is the store snapshots are fetched from during a restoration.
+	// It is the configured store, wrapped with a download throttle when one is
+	// set in the restore options.
+	fetchStore brtypes.SnapStore
+	// ProgressReporter, when set, is called with the latest restoration progress
+	// whenever it changes.
+	ProgressReporter func(progress brtypes.RestoreProgress)
+	progressMutex    sync.Mutex
+	progress         brtypes.RestoreProgress
 }
 
 // NewRestorer returns the restorer object.
 func NewRestorer(store brtypes.SnapStore, logger *logrus.Entry) *Restorer {
 	zapLogger, _ := zap.NewProduction()
 	return &Restorer{
-		logger:    logger.WithField("actor", "restorer"),
-		zapLogger: zapLogger,
-		store:     store,
+		logger:     logger.WithField("actor", "restorer"),
+		zapLogger:  zapLogger,
+		store:      store,
+		fetchStore: store,
 	}
 }
 
@@ -92,13 +106,208 @@ func (r *Restorer) RestoreAndStopEtcd(ro brtypes.RestoreOptions, m member.Contro
 
 // Restore restore the etcd data directory as per specified restore options but returns the ETCD server that it statrted.
 func (r *Restorer) Restore(ro brtypes.RestoreOptions, m member.Control) (*embed.Etcd, error) {
-	if err := r.restoreFromBaseSnapshot(ro); err != nil {
-		return nil, fmt.Errorf("failed to restore from the base snapshot :%v", err)
+	var currentSnapshot string
+	if ro.BaseSnapshot != nil {
+		currentSnapshot = ro.BaseSnapshot.SnapName
 	}
-	if len(ro.DeltaSnapList) == 0 {
+	r.updateProgress(func(p *brtypes.RestoreProgress) {
+		*p = brtypes.RestoreProgress{
+			Phase:           brtypes.RestorePhaseFetching,
+			CurrentSnapshot: currentSnapshot,
+			DeltasTotal:     len(ro.DeltaSnapList),
+		}
+	})
+	e, err := r.restoreWithFallback(ro, m)
+	if err != nil {
+		r.updateProgress(func(p *brtypes.RestoreProgress) {
+			p.Phase = brtypes.RestorePhaseFailed
+			p.Message = err.Error()
+		})
+		return e, err
+	}
+	r.updateProgress(func(p *brtypes.RestoreProgress) {
+		p.Phase = brtypes.RestorePhaseDone
+		if p.CurrentRevision == 0 && ro.BaseSnapshot != nil {
+			p.CurrentRevision = ro.BaseSnapshot.LastRevision
+		}
+	})
+	return e, nil
+}
+
+// updateProgress mutates the restoration progress under the progress mutex and
+// passes a copy of the result to the configured progress reporter, if any.
+func (r *Restorer) updateProgress(mutate func(progress *brtypes.RestoreProgress)) {
+	r.progressMutex.Lock()
+	mutate(&r.progress)
+	progress := r.progress
+	r.progressMutex.Unlock()
+	if r.ProgressReporter != nil {
+		r.ProgressReporter(progress)
+	}
+}
+
+// restoreWithFallback restores the etcd data directory, backing up an existing
+// member directory beforehand and rolling back to it on failure if configured.
+func (r *Restorer) restoreWithFallback(ro brtypes.RestoreOptions, m member.Control) (*embed.Etcd, error) {
+	if err := r.validateDiskSpace(ro); err != nil {
+		return nil, err
+	}
+	backupDir, err := r.backupExistingDataDir(ro)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up the existing data directory: %v", err)
+	}
+	e, err := r.restore(ro, m)
+	if backupDir == "" {
+		return e, err
+	}
+	if err != nil {
+		if e != nil {
+			e.Server.Stop()
+			e.Close()
+			e = nil
+		}
+		if rollbackErr := r.rollbackExistingDataDir(ro, backupDir); rollbackErr != nil {
+			r.logger.Errorf("Failed to roll back the previous data directory from %s: %v", backupDir, rollbackErr)
+		} else {
+			r.logger.Infof("Restoration failed. Rolled back the previous data directory from %s.", backupDir)
+		}
+		return e, err
+	}
+	r.logger.Infof("Backup of the previous data directory left at %s for manual cleanup.", backupDir)
+	return e, nil
+}
+
+// validateDiskSpace estimates the space required for the restoration from the
+// sizes of the base snapshot and the delta chain and aborts early when the
+// filesystem of the data directory does not have enough space left. The check
+// is skipped when the snapstore backend cannot report snapshot sizes.
+func (r *Restorer) validateDiskSpace(ro brtypes.RestoreOptions) error {
+	sizedStore, ok := r.store.(brtypes.SizedSnapStore)
+	if !ok {
+		r.logger.Info("Skipping disk space validation as the snapstore backend does not report snapshot sizes.")
+		return nil
+	}
+
+	var requiredSpace int64
+	if ro.BaseSnapshot != nil && len(ro.BaseSnapshot.SnapName) != 0 {
+		size, err := sizedStore.Size(*ro.BaseSnapshot)
+		if err != nil {
+			return fmt.Errorf("failed to get size of base snapshot %s: %v", ro.BaseSnapshot.SnapName, err)
+		}
+		requiredSpace += size
+	}
+	for _, deltaSnap := range ro.DeltaSnapList {
+		size, err := sizedStore.Size(*deltaSnap)
+		if err != nil {
+			return fmt.Errorf("failed to get size of delta snapshot %s: %v", deltaSnap.SnapName, err)
+		}
+		requiredSpace += size
+	}
+	if requiredSpace == 0 {
+		return nil
+	}
+	requiredSpace += requiredSpace * int64(ro.Config.DiskSpaceSafetyMarginPercent) / 100
+
+	availableSpace, err := availableDiskSpace(ro.Config.RestoreDataDir)
+	if err != nil {
+		return fmt.Errorf("failed to get available disk space for data directory %s: %v", ro.Config.RestoreDataDir, err)
+	}
+	if availableSpace < requiredSpace {
+		return fmt.Errorf("insufficient disk space for restoration: required %d bytes including %d%% safety margin, available %d bytes", requiredSpace, ro.Config.DiskSpaceSafetyMarginPercent, availableSpace)
+	}
+	r.logger.Infof("Disk space validation succeeded: required %d bytes including %d%% safety margin, available %d bytes.", requiredSpace, ro.Config.DiskSpaceSafetyMarginPercent, availableSpace)
+	return nil
+}
+
+// availableDiskSpace returns the number of bytes available to the process on
+// the filesystem containing the given path, walking up to the nearest existing
+// ancestor if the path does not exist yet.
+func availableDiskSpace(dir string) (int64, error) {
+	dir = filepath.Clean(dir)
+	for {
+		_, err := os.Stat(dir)
+		if err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			return 0, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return 0, fmt.Errorf("no existing ancestor directory found for %s", dir)
+		}
+		dir = parent
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// backupExistingDataDir moves an existing member directory aside to a timestamped
+// backup location so that the restoration can fall back to it on failure.
+func (r *Restorer) backupExistingDataDir(ro brtypes.RestoreOptions) (string, error) {
+	if !ro.Config.BackupExistingDataDir {
+		return "", nil
+	}
+	memberDir := filepath.Join(ro.Config.RestoreDataDir, "member")
+	if _, err := os.Stat(memberDir); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	backupDir := fmt.Sprintf("%s.backup-%d", memberDir, time.Now().Unix())
+	r.logger.Infof("Moving the existing member directory to %s before restoration.", backupDir)
+	if err := os.Rename(memberDir, backupDir); err != nil {
+		return "", err
+	}
+	return backupDir, nil
+}
+
+// rollbackExistingDataDir moves the member directory back from the given backup
+// location, discarding whatever the failed restoration left behind.
+func (r *Restorer) rollbackExistingDataDir(ro brtypes.RestoreOptions, backupDir string) error {
+	memberDir := filepath.Join(ro.Config.RestoreDataDir, "member")
+	if err := os.RemoveAll(memberDir); err != nil {
+		return err
+	}
+	return os.Rename(backupDir, memberDir)
+}
+
+// restore restores the etcd data directory from the snapshots in the restore options.
+func (r *Restorer) restore(ro brtypes.RestoreOptions, m member.Control) (*embed.Etcd, error) {
+	if ro.BaseSnapshot == nil {
+		return nil, fmt.Errorf("%w in the snapstore to restore from", ErrNoSnapshotsFound)
+	}
+	// Throttle downloads so that snapshot transfers do not saturate the network.
+	// The configured store is wrapped afresh on every restoration so that
+	// repeated restorations do not stack throttles.
+	r.fetchStore = r.store
+	if ro.Config.DownloadBytesPerSecond > 0 {
+		r.fetchStore = snapstore.NewThrottledSnapStore(r.store, 0, ro.Config.DownloadBytesPerSecond)
+	}
+	baseEvents, isKeyRangeSnapshot, err := r.restoreFromBaseSnapshot(ro)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore from the base snapshot :%w", err)
+	}
+	if ro.BaseSnapshot != nil {
+		r.updateProgress(func(p *brtypes.RestoreProgress) {
+			p.CurrentRevision = ro.BaseSnapshot.LastRevision
+		})
+	}
+	if len(ro.DeltaSnapList) == 0 && !isKeyRangeSnapshot {
 		r.logger.Infof("No delta snapshots present over base snapshot.")
 		return nil, nil
 	}
+	// The embedded etcd used to replay delta snapshots runs as a lone member on
+	// ephemeral localhost URLs. With a multi-member membership baked into the
+	// data directory it could never win an election, so fail fast instead of
+	// waiting for its startup to time out.
+	if len(ro.ClusterURLs) > 1 {
+		return nil, fmt.Errorf("delta snapshots cannot be applied when restoring a member of a multi-member cluster: restore from a full snapshot only")
+	}
 	r.logger.Infof("Starting embedded etcd server...")
 	e, err := miscellaneous.StartEmbeddedEtcd(r.logger, &ro)
 	if err != nil {
@@ -116,9 +325,18 @@ func (r *Restorer) Restore(ro brtypes.RestoreOptions, m member.Control) (*embed.
 	}
 	defer clientKV.Close()
 
-	r.logger.Infof("Applying delta snapshots...")
-	if err := r.applyDeltaSnapshots(clientKV, ro); err != nil {
-		return e, err
+	if isKeyRangeSnapshot {
+		r.logger.Infof("Applying %d keys from the key-range base snapshot...", len(baseEvents))
+		if err := applyEventsToEtcd(clientKV, baseEvents); err != nil {
+			return e, fmt.Errorf("%w: failed to apply key-range base snapshot: %v", ErrEtcdReplay, err)
+		}
+	}
+
+	if len(ro.DeltaSnapList) > 0 {
+		r.logger.Infof("Applying delta snapshots...")
+		if err := r.applyDeltaSnapshots(clientKV, ro, isKeyRangeSnapshot); err != nil {
+			return e, err
+		}
 	}
 
 	if m != nil {
@@ -133,11 +351,13 @@ func (r *Restorer) Restore(ro brtypes.RestoreOptions, m member.Control) (*embed.
 }
 
 // restoreFromBaseSnapshot restore the etcd data directory from base snapshot.
-func (r *Restorer) restoreFromBaseSnapshot(ro brtypes.RestoreOptions) error {
+// For key-range base snapshots it returns the exported events instead, which
+// are replayed through the embedded etcd after it is started.
+func (r *Restorer) restoreFromBaseSnapshot(ro brtypes.RestoreOptions) ([]brtypes.Event, bool, error) {
 	var err error
 	if path.Join(ro.BaseSnapshot.SnapDir, ro.BaseSnapshot.SnapName) == "" {
 		r.logger.Warnf("Base snapshot path not provided. Will do nothing.")
-		return nil
+		return nil, false, nil
 	}
 	r.logger.Infof("Restoring from base snapshot: %s", path.Join(ro.BaseSnapshot.SnapDir, ro.BaseSnapshot.SnapName))
 	cfg := etcdserver.ServerConfig{
@@ -147,30 +367,81 @@ func (r *Restorer) restoreFromBaseSnapshot(ro brtypes.RestoreOptions) error {
 		Name:                ro.Config.Name,
 	}
 	if err := cfg.VerifyBootstrap(); err != nil {
-		return err
+		return nil, false, err
 	}
 
 	cl, err := membership.NewClusterFromURLsMap(r.zapLogger, ro.Config.InitialClusterToken, ro.ClusterURLs)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 
 	memberDir := filepath.Join(ro.Config.RestoreDataDir, "member")
 	if _, err := os.Stat(memberDir); err == nil {
-		return fmt.Errorf("member directory in data directory(%q) exists", memberDir)
+		return nil, false, fmt.Errorf("member directory in data directory(%q) exists", memberDir)
+	}
+
+	// A key-range snapshot cannot be laid down as an etcd database; its keys
+	// are replayed through the embedded etcd instead.
+	baseEvents, isKeyRangeSnapshot, err := r.getKeyRangeSnapshotEvents(ro.BaseSnapshot)
+	if err != nil {
+		return nil, isKeyRangeSnapshot, err
+	}
+	if isKeyRangeSnapshot {
+		return baseEvents, true, nil
 	}
 
 	walDir := filepath.Join(memberDir, "wal")
 	snapdir := filepath.Join(memberDir, "snap")
 	if err = r.makeDB(snapdir, ro.BaseSnapshot, len(cl.Members()), ro.Config.SkipHashCheck); err != nil {
-		return err
+		return nil, false, err
 	}
-	return makeWALAndSnap(r.zapLogger, walDir, snapdir, cl, ro.Config.Name)
+	return nil, false, makeWALAndSnap(r.zapLogger, walDir, snapdir, cl, ro.Config.Name)
+}
+
+// getKeyRangeSnapshotEvents checks whether the base snapshot is a key-range
+// snapshot by sniffing its payload header, and if so returns its decoded events.
+func (r *Restorer) getKeyRangeSnapshotEvents(snap *brtypes.Snapshot) ([]brtypes.Event, bool, error) {
+	rc, err := r.fetchStore.Fetch(*snap)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+
+	isCompressed, compressionPolicy, err := compressor.IsSnapshotCompressed(snap.CompressionSuffix)
+	if err != nil {
+		return nil, false, err
+	}
+	var reader io.Reader = rc
+	if isCompressed {
+		drc, err := compressor.DecompressSnapshot(rc, compressionPolicy)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to decompress the snapshot: %v", err)
+		}
+		defer drc.Close()
+		reader = drc
+	}
+
+	br := bufio.NewReader(reader)
+	peek, err := br.Peek(len(etcdutil.KeyRangeSnapshotMagic))
+	if err != nil || !bytes.Equal(peek, etcdutil.KeyRangeSnapshotMagic) {
+		// too short or no magic header, so a regular database snapshot
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, true, err
+	}
+	events, err := etcdutil.DecodeKeyRangeSnapshot(data)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read events from key-range base snapshot %s : %v", snap.SnapName, err)
+	}
+	return events, true, nil
 }
 
 // makeDB copies the database snapshot to the snapshot directory.
 func (r *Restorer) makeDB(snapdir string, snap *brtypes.Snapshot, commit int, skipHashCheck bool) error {
-	rc, err := r.store.Fetch(*snap)
+	rc, err := r.fetchStore.Fetch(*snap)
 	if err != nil {
 		return err
 	}
@@ -216,7 +487,7 @@ func (r *Restorer) makeDB(snapdir string, snap *brtypes.Snapshot, commit int, sk
 	}
 	hasHash := (off % 512) == sha256.Size
 	if !hasHash && !skipHashCheck {
-		err := fmt.Errorf("snapshot missing hash but --skip-hash-check=false")
+		err := fmt.Errorf("%w: snapshot missing hash but --skip-hash-check=false", ErrCorruptSnapshot)
 		return err
 	}
 
@@ -246,7 +517,7 @@ func (r *Restorer) makeDB(snapdir string, snap *brtypes.Snapshot, commit int, sk
 			}
 			dbSha := h.Sum(nil)
 			if !reflect.DeepEqual(sha, dbSha) {
-				err := fmt.Errorf("expected sha256 %v, got %v", sha, dbSha)
+				err := fmt.Errorf("%w: expected sha256 %v, got %v", ErrCorruptSnapshot, sha, dbSha)
 				return err
 			}
 		}
@@ -369,17 +640,38 @@ func makeWALAndSnap(logger *zap.Logger, waldir, snapdir string, cl *membership.R
 }
 
 // applyDeltaSnapshots fetches the events from delta snapshots in parallel and applies them to the embedded etcd sequentially.
-func (r *Restorer) applyDeltaSnapshots(clientKV client.KVCloser, ro brtypes.RestoreOptions) error {
+// applyDeltaSnapshots applies the delta snapshots to the embedded etcd. When
+// restoring on top of a key-range base snapshot the revisions of the embedded
+// etcd do not match the revisions recorded in the snapshots, so the revision
+// verification after each applied snapshot is skipped.
+func (r *Restorer) applyDeltaSnapshots(clientKV client.KVCloser, ro brtypes.RestoreOptions, skipRevisionVerify bool) error {
 	snapList := ro.DeltaSnapList
 	numMaxFetchers := ro.Config.MaxFetchers
 
+	if err := verifyDeltaSnapshotChain(ro.BaseSnapshot, snapList); err != nil {
+		if !ro.Config.AllowDeltaSnapshotGaps {
+			return err
+		}
+		r.logger.Warnf("Proceeding to restore across delta snapshot gap: %v", err)
+	}
+
 	firstDeltaSnap := snapList[0]
 
+	r.updateProgress(func(p *brtypes.RestoreProgress) {
+		p.Phase = brtypes.RestorePhaseApplying
+		p.CurrentSnapshot = firstDeltaSnap.SnapName
+	})
 	if err := r.applyFirstDeltaSnapshot(clientKV, *firstDeltaSnap); err != nil {
 		return err
 	}
-	if err := verifySnapshotRevision(clientKV, snapList[0]); err != nil {
-		return err
+	r.updateProgress(func(p *brtypes.RestoreProgress) {
+		p.DeltasApplied = 1
+		p.CurrentRevision = firstDeltaSnap.LastRevision
+	})
+	if !skipRevisionVerify {
+		if err := verifySnapshotRevision(clientKV, snapList[0]); err != nil {
+			return err
+		}
 	}
 
 	// no more delta snapshots available
@@ -399,10 +691,10 @@ func (r *Restorer) applyDeltaSnapshots(clientKV client.KVCloser, ro brtypes.Rest
 		wg              sync.WaitGroup
 	)
 
-	go r.applySnaps(clientKV, remainingSnaps, applierInfoCh, errCh, stopCh, &wg)
+	go r.applySnaps(clientKV, remainingSnaps, skipRevisionVerify, applierInfoCh, errCh, stopCh, &wg)
 
 	for f := 0; f < numFetchers; f++ {
-		go r.fetchSnaps(f, fetcherInfoCh, applierInfoCh, snapLocationsCh, errCh, stopCh, &wg)
+		go r.fetchSnaps(f, fetcherInfoCh, applierInfoCh, snapLocationsCh, errCh, stopCh, &wg, ro.Config.TempDir)
 	}
 
 	for i, snap := range remainingSnaps {
@@ -444,7 +736,7 @@ func (r *Restorer) cleanup(snapLocationsCh chan string, stopCh chan bool, wg *sy
 }
 
 // fetchSnaps fetches delta snapshots as events and persists them onto disk.
-func (r *Restorer) fetchSnaps(fetcherIndex int, fetcherInfoCh <-chan brtypes.FetcherInfo, applierInfoCh chan<- brtypes.ApplierInfo, snapLocationsCh chan<- string, errCh chan<- error, stopCh chan bool, wg *sync.WaitGroup) {
+func (r *Restorer) fetchSnaps(fetcherIndex int, fetcherInfoCh <-chan brtypes.FetcherInfo, applierInfoCh chan<- brtypes.ApplierInfo, snapLocationsCh chan<- string, errCh chan<- error, stopCh chan bool, wg *sync.WaitGroup, tempDir string) {
 	defer wg.Done()
 	wg.Add(1)
 
@@ -459,12 +751,12 @@ func (r *Restorer) fetchSnaps(fetcherIndex int, fetcherInfoCh <-chan brtypes.Fet
 
 			eventsData, err := r.getEventsDataFromDeltaSnapshot(fetcherInfo.Snapshot)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to read events data from delta snapshot %s : %v", fetcherInfo.Snapshot.SnapName, err)
+				errCh <- fmt.Errorf("failed to read events data from delta snapshot %s : %w", fetcherInfo.Snapshot.SnapName, err)
 				applierInfoCh <- brtypes.ApplierInfo{SnapIndex: -1} // cannot use close(ch) as concurrent fetchSnaps routines might try to send on channel, causing a panic
 				return
 			}
 
-			eventsFilePath, err := persistDeltaSnapshot(eventsData)
+			eventsFilePath, err := persistDeltaSnapshot(eventsData, tempDir)
 			if err != nil {
 				errCh <- fmt.Errorf("failed to persist events data for delta snapshot %s : %v", fetcherInfo.Snapshot.SnapName, err)
 				applierInfoCh <- brtypes.ApplierInfo{SnapIndex: -1}
@@ -483,7 +775,7 @@ func (r *Restorer) fetchSnaps(fetcherIndex int, fetcherInfoCh <-chan brtypes.Fet
 }
 
 // applySnaps applies delta snapshot events to the embedded etcd sequentially, in the right order of snapshots, regardless of the order in which they were fetched.
-func (r *Restorer) applySnaps(clientKV client.KVCloser, remainingSnaps brtypes.SnapList, applierInfoCh <-chan brtypes.ApplierInfo, errCh chan<- error, stopCh <-chan bool, wg *sync.WaitGroup) {
+func (r *Restorer) applySnaps(clientKV client.KVCloser, remainingSnaps brtypes.SnapList, skipRevisionVerify bool, applierInfoCh <-chan brtypes.ApplierInfo, errCh chan<- error, stopCh <-chan bool, wg *sync.WaitGroup) {
 	defer wg.Done()
 	wg.Add(1)
 
@@ -515,6 +807,9 @@ func (r *Restorer) applySnaps(clientKV client.KVCloser, remainingSnaps brtypes.S
 					}
 
 					r.logger.Infof("Applying delta snapshot %s", path.Join(remainingSnaps[currSnapIndex].SnapDir, remainingSnaps[currSnapIndex].SnapName))
+					r.updateProgress(func(p *brtypes.RestoreProgress) {
+						p.CurrentSnapshot = remainingSnaps[currSnapIndex].SnapName
+					})
 
 					filePath := pathList[currSnapIndex]
 					snapName := remainingSnaps[currSnapIndex].SnapName
@@ -527,16 +822,20 @@ func (r *Restorer) applySnaps(clientKV client.KVCloser, remainingSnaps brtypes.S
 					if err = os.Remove(filePath); err != nil {
 						r.logger.Warnf("Unable to remove file: %s; err: %v", filePath, err)
 					}
-					events := []brtypes.Event{}
-					if err = json.Unmarshal(eventsData, &events); err != nil {
+					events, err := deltacodec.DecodeEvents(eventsData)
+					if err != nil {
 						errCh <- fmt.Errorf("failed to read events from events data for delta snapshot %s : %v", snapName, err)
 						return
 					}
 
-					if err := applyEventsAndVerify(clientKV, events, remainingSnaps[currSnapIndex]); err != nil {
+					if err := applyEventsAndVerify(clientKV, events, remainingSnaps[currSnapIndex], skipRevisionVerify); err != nil {
 						errCh <- err
 						return
 					}
+					r.updateProgress(func(p *brtypes.RestoreProgress) {
+						p.DeltasApplied++
+						p.CurrentRevision = remainingSnaps[currSnapIndex].LastRevision
+					})
 					nextSnapIndexToApply++
 					if nextSnapIndexToApply == len(remainingSnaps) {
 						errCh <- nil // restore finished
@@ -549,13 +848,16 @@ func (r *Restorer) applySnaps(clientKV client.KVCloser, remainingSnaps brtypes.S
 }
 
 // applyEventsAndVerify applies events from one snapshot to the embedded etcd and verifies the correctness of the sequence of snapshot applied.
-func applyEventsAndVerify(clientKV client.KVCloser, events []brtypes.Event, snap *brtypes.Snapshot) error {
+func applyEventsAndVerify(clientKV client.KVCloser, events []brtypes.Event, snap *brtypes.Snapshot, skipRevisionVerify bool) error {
 	if err := applyEventsToEtcd(clientKV, events); err != nil {
-		return fmt.Errorf("failed to apply events to etcd for delta snapshot %s : %v", snap.SnapName, err)
+		return fmt.Errorf("%w: failed to apply events to etcd for delta snapshot %s : %v", ErrEtcdReplay, snap.SnapName, err)
 	}
 
+	if skipRevisionVerify {
+		return nil
+	}
 	if err := verifySnapshotRevision(clientKV, snap); err != nil {
-		return fmt.Errorf("snapshot revision verification failed for delta snapshot %s : %v", snap.SnapName, err)
+		return fmt.Errorf("%w: snapshot revision verification failed for delta snapshot %s : %v", ErrEtcdReplay, snap.SnapName, err)
 	}
 	return nil
 }
@@ -565,7 +867,7 @@ func (r *Restorer) applyFirstDeltaSnapshot(clientKV client.KVCloser, snap brtype
 	r.logger.Infof("Applying first delta snapshot %s", path.Join(snap.SnapDir, snap.SnapName))
 	events, err := r.getEventsFromDeltaSnapshot(snap)
 	if err != nil {
-		return fmt.Errorf("failed to read events from delta snapshot %s : %v", snap.SnapName, err)
+		return fmt.Errorf("failed to read events from delta snapshot %s : %w", snap.SnapName, err)
 	}
 
 	// Note: Since revision in full snapshot file name might be lower than actual revision stored in snapshot.
@@ -588,7 +890,10 @@ func (r *Restorer) applyFirstDeltaSnapshot(clientKV client.KVCloser, snap brtype
 		}
 	}
 
-	return applyEventsToEtcd(clientKV, events[newRevisionIndex:])
+	if err := applyEventsToEtcd(clientKV, events[newRevisionIndex:]); err != nil {
+		return fmt.Errorf("%w: failed to apply events to etcd for delta snapshot %s : %v", ErrEtcdReplay, snap.SnapName, err)
+	}
+	return nil
 }
 
 // getEventsFromDeltaSnapshot returns the events from delta snapshot from snap store.
@@ -598,17 +903,12 @@ func (r *Restorer) getEventsFromDeltaSnapshot(snap brtypes.Snapshot) ([]brtypes.
 		return nil, err
 	}
 
-	events := []brtypes.Event{}
-	if err := json.Unmarshal(data, &events); err != nil {
-		return nil, err
-	}
-
-	return events, nil
+	return deltacodec.DecodeEvents(data)
 }
 
 // getEventsDataFromDeltaSnapshot fetches the events data from delta snapshot from snap store.
 func (r *Restorer) getEventsDataFromDeltaSnapshot(snap brtypes.Snapshot) ([]byte, error) {
-	rc, err := r.store.Fetch(snap)
+	rc, err := r.fetchStore.Fetch(snap)
 	if err != nil {
 		return nil, err
 	}
@@ -642,7 +942,7 @@ func (r *Restorer) getEventsDataFromDeltaSnapshot(snap brtypes.Snapshot) ([]byte
 	sha := buf.Bytes()
 
 	if bufSize <= sha256.Size {
-		return nil, fmt.Errorf("delta snapshot is missing hash")
+		return nil, fmt.Errorf("%w: delta snapshot is missing hash", ErrCorruptSnapshot)
 	}
 	data := sha[:bufSize-sha256.Size]
 	snapHash := sha[bufSize-sha256.Size:]
@@ -655,15 +955,18 @@ func (r *Restorer) getEventsDataFromDeltaSnapshot(snap brtypes.Snapshot) ([]byte
 
 	computedSha := h.Sum(nil)
 	if !reflect.DeepEqual(snapHash, computedSha) {
-		return nil, fmt.Errorf("expected sha256 %v, got %v", snapHash, computedSha)
+		return nil, fmt.Errorf("%w: expected sha256 %v, got %v", ErrCorruptSnapshot, snapHash, computedSha)
 	}
 
 	return data, nil
 }
 
 // persistDeltaSnapshot writes delta snapshot events to disk and returns the file path for the same.
-func persistDeltaSnapshot(data []byte) (string, error) {
-	tmpFile, err := os.CreateTemp(tmpDir, tmpEventsDataFilePrefix)
+func persistDeltaSnapshot(data []byte, tempDir string) (string, error) {
+	if tempDir == "" {
+		tempDir = tmpDir
+	}
+	tmpFile, err := os.CreateTemp(tempDir, tmpEventsDataFilePrefix)
 	if err != nil {
 		err = fmt.Errorf("failed to create temp file")
 		return "", err
@@ -710,6 +1013,28 @@ func applyEventsToEtcd(clientKV client.KVCloser, events []brtypes.Event) error {
 	return err
 }
 
+// verifyDeltaSnapshotChain verifies that each delta snapshot's start revision is
+// exactly one past the previous snapshot's last revision, so that a missing delta
+// snapshot does not silently produce an inconsistent restore.
+func verifyDeltaSnapshotChain(baseSnapshot *brtypes.Snapshot, deltaSnapList brtypes.SnapList) error {
+	var (
+		prevSnapName      string
+		nextStartRevision int64
+	)
+	if baseSnapshot != nil {
+		prevSnapName = baseSnapshot.SnapName
+		nextStartRevision = baseSnapshot.LastRevision + 1
+	}
+	for _, snap := range deltaSnapList {
+		if prevSnapName != "" && snap.StartRevision != nextStartRevision {
+			return fmt.Errorf("%w: delta snapshot %s starts at revision %d, but previous snapshot %s requires it to start at revision %d", ErrDeltaGap, snap.SnapName, snap.StartRevision, prevSnapName, nextStartRevision)
+		}
+		prevSnapName = snap.SnapName
+		nextStartRevision = snap.LastRevision + 1
+	}
+	return nil
+}
+
 func verifySnapshotRevision(clientKV client.KVCloser, snap *brtypes.Snapshot) error {
 	ctx := context.TODO()
 	getResponse, err := clientKV.Get(ctx, "foo")
@@ -16,6 +16,7 @@ package restorer_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -25,11 +26,13 @@ import (
 	"time"
 
 	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/etcdutil"
 	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
 	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
 	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
 	"github.com/gardener/etcd-backup-restore/test/utils"
 	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/pkg/types"
 
 	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
@@ -213,6 +216,83 @@ var _ = Describe("Running Restorer", func() {
 				Expect(err).ShouldNot(HaveOccurred())
 			})
 		})
+
+		Context("with the restored data directory verified offline", func() {
+			It("should verify the live key count and maximum revision", func() {
+				err = rstr.RestoreAndStopEtcd(restoreOpts, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				// every 10th key, including key 0, was deleted again while populating etcd
+				expectedKeyCount := keyTo - keyTo/10
+				expectedRevision := restoreOpts.DeltaSnapList[len(restoreOpts.DeltaSnapList)-1].LastRevision
+				Expect(VerifyConsistency(restoreOpts.Config.RestoreDataDir, expectedKeyCount, expectedRevision)).Should(Succeed())
+
+				err = VerifyConsistency(restoreOpts.Config.RestoreDataDir, expectedKeyCount+1, expectedRevision+1)
+				Expect(err).Should(HaveOccurred())
+				Expect(err.Error()).Should(ContainSubstring(fmt.Sprintf("expected %d live keys, found %d", expectedKeyCount+1, expectedKeyCount)))
+				Expect(err.Error()).Should(ContainSubstring(fmt.Sprintf("expected maximum revision %d, found %d", expectedRevision+1, expectedRevision)))
+			})
+		})
+
+		Context("with a gap in the delta snapshot chain", func() {
+			It("should fail to restore by default", func() {
+				Expect(len(restoreOpts.DeltaSnapList)).Should(BeNumerically(">", 2))
+				// drop a delta snapshot from the middle of the chain
+				restoreOpts.DeltaSnapList = append(brtypes.SnapList{restoreOpts.DeltaSnapList[0]}, restoreOpts.DeltaSnapList[2:]...)
+
+				err = rstr.RestoreAndStopEtcd(restoreOpts, nil)
+				Expect(err).Should(HaveOccurred())
+				Expect(errors.Is(err, ErrDeltaGap)).Should(BeTrue())
+				Expect(err.Error()).Should(ContainSubstring("gap in delta snapshot chain"))
+			})
+		})
+
+		Context("with backup of existing data directory enabled", func() {
+			var (
+				memberDir  string
+				markerFile string
+			)
+
+			BeforeEach(func() {
+				memberDir = path.Join(etcdDir, "member")
+				markerFile = path.Join(memberDir, "marker")
+				err = os.MkdirAll(memberDir, 0700)
+				Expect(err).ShouldNot(HaveOccurred())
+				err = os.WriteFile(markerFile, []byte("previous data"), 0600)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				restoreOpts.Config.BackupExistingDataDir = true
+			})
+
+			It("should roll back the previous member directory when restoration fails", func() {
+				restoreOpts.BaseSnapshot.SnapDir = "test"
+				restoreOpts.BaseSnapshot.SnapName = "test"
+
+				err = rstr.RestoreAndStopEtcd(restoreOpts, nil)
+				Expect(err).Should(HaveOccurred())
+
+				data, err := os.ReadFile(markerFile)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(data)).Should(Equal("previous data"))
+				Expect(listBackupDirs(etcdDir)).Should(BeEmpty())
+			})
+
+			It("should keep the previous member directory at the backup location when restoration succeeds", func() {
+				err = rstr.RestoreAndStopEtcd(restoreOpts, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				err = utils.CheckDataConsistency(testCtx, restoreOpts.Config.RestoreDataDir, keyTo, logger)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = os.Stat(markerFile)
+				Expect(os.IsNotExist(err)).Should(BeTrue())
+				backupDirs := listBackupDirs(etcdDir)
+				Expect(backupDirs).Should(HaveLen(1))
+				data, err := os.ReadFile(path.Join(etcdDir, backupDirs[0], "marker"))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(data)).Should(Equal("previous data"))
+			})
+		})
 	})
 
 	Describe("NEGATIVE: Negative Compression Scenarios", func() {
@@ -331,6 +411,58 @@ var _ = Describe("Running Restorer", func() {
 			})
 		})
 
+		Context("with a key prefix filter applied to the snapshots", func() {
+			It("should restore only the keys under the prefix", func() {
+				cli, err := clientv3.New(clientv3.Config{Endpoints: endpoints})
+				Expect(err).ShouldNot(HaveOccurred())
+				for i := 0; i < 10; i++ {
+					_, err = cli.Put(testCtx, fmt.Sprintf("/app/key-%d", i), fmt.Sprintf("value-%d", i))
+					Expect(err).ShouldNot(HaveOccurred())
+					_, err = cli.Put(testCtx, fmt.Sprintf("/other/key-%d", i), fmt.Sprintf("value-%d", i))
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+				resp, err := cli.Get(testCtx, "", clientv3.WithLastRev()...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = etcdutil.TakeAndSaveKeyRangeSnapshot(testCtx, cli, store, []string{"/app/"}, resp.Header.Revision, compressor.NewCompressorConfig(), "", "", false, logger)
+				Expect(err).ShouldNot(HaveOccurred())
+				cli.Close()
+
+				etcd.Server.Stop()
+				etcd.Close()
+				err = corruptEtcdDir()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				baseSnapshot, _, err = miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(baseSnapshot).ShouldNot(BeNil())
+
+				rstr = NewRestorer(store, logger)
+				restoreOpts := brtypes.RestoreOptions{
+					Config:       restorationConfig,
+					BaseSnapshot: baseSnapshot,
+					ClusterURLs:  clusterUrlsMap,
+					PeerURLs:     peerUrls,
+				}
+
+				err = rstr.RestoreAndStopEtcd(restoreOpts, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				etcd, err = utils.StartEmbeddedEtcd(testCtx, etcdDir, logger, embeddedEtcdPortNo)
+				Expect(err).ShouldNot(HaveOccurred())
+				cli, err = clientv3.New(clientv3.Config{Endpoints: []string{etcd.Clients[0].Addr().String()}})
+				Expect(err).ShouldNot(HaveOccurred())
+				defer cli.Close()
+
+				appResp, err := cli.Get(testCtx, "/app/", clientv3.WithPrefix())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(appResp.Kvs).Should(HaveLen(10))
+				otherResp, err := cli.Get(testCtx, "/other/", clientv3.WithPrefix())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(otherResp.Kvs).Should(BeEmpty())
+			})
+		})
+
 		Context("with no delta snapshots", func() {
 			It("Should restore only full snapshot", func() {
 				deltaSnapshotPeriod = time.Duration(0)
@@ -928,6 +1060,20 @@ func corruptEtcdDir() error {
 	return os.RemoveAll(etcdDir)
 }
 
+// listBackupDirs lists the names of the member directory backups taken by the
+// restorer in the given data directory.
+func listBackupDirs(dataDir string) []string {
+	entries, err := os.ReadDir(dataDir)
+	Expect(err).ShouldNot(HaveOccurred())
+	var backupDirs []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "member.backup-") {
+			backupDirs = append(backupDirs, entry.Name())
+		}
+	}
+	return backupDirs
+}
+
 // takeValidSnaps saves valid snaps in the v1 prefix dir of snapstore so that restorer could restore from them
 // TODO: Consider removing when backward compatibility no longer needed
 func takeValidSnaps(logger *logrus.Entry, container string, resp *utils.EtcdDataPopulationResponse, deltaSnapshotPeriod time.Duration, endpoints []string, mode int, backupVersion int) error {
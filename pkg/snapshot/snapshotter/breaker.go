@@ -0,0 +1,108 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// breakerClosed is set while snapshot attempts are allowed.
+	breakerClosed = "Closed"
+	// breakerOpen is set while snapshot attempts are skipped after repeated failures.
+	breakerOpen = "Open"
+	// breakerHalfOpen is set while a single probe attempt is allowed after the open window elapsed.
+	breakerHalfOpen = "HalfOpen"
+)
+
+// circuitBreaker tracks consecutive snapshot failures and skips further
+// attempts for an open window once the configured failure threshold is
+// reached. A threshold of 0 disables the breaker so that snapshots are
+// always attempted.
+type circuitBreaker struct {
+	mutex               sync.Mutex
+	state               string
+	consecutiveFailures uint
+	failureThreshold    uint
+	openWindow          time.Duration
+	openedAt            time.Time
+}
+
+// newCircuitBreaker returns a circuit breaker in the closed state.
+func newCircuitBreaker(failureThreshold uint, openWindow time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		state:            breakerClosed,
+		failureThreshold: failureThreshold,
+		openWindow:       openWindow,
+	}
+}
+
+// allowAttempt reports whether a snapshot attempt may be made. While open,
+// attempts are denied until the open window has elapsed, after which a single
+// probe attempt is allowed in the half-open state.
+func (cb *circuitBreaker) allowAttempt() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.failureThreshold == 0 {
+		return true
+	}
+	switch cb.state {
+	case breakerOpen:
+		if time.Since(cb.openedAt) < cb.openWindow {
+			return false
+		}
+		cb.state = breakerHalfOpen
+		return true
+	default:
+		return true
+	}
+}
+
+// recordSuccess closes the breaker and resets the consecutive failure count.
+func (cb *circuitBreaker) recordSuccess() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.consecutiveFailures = 0
+	cb.state = breakerClosed
+	metrics.SnapshotterBreakerOpen.With(prometheus.Labels{}).Set(0)
+}
+
+// recordFailure counts a failed snapshot attempt and opens the breaker once
+// the failure threshold is reached or a half-open probe attempt fails.
+func (cb *circuitBreaker) recordFailure() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.failureThreshold == 0 {
+		return
+	}
+	cb.consecutiveFailures++
+	if cb.state == breakerHalfOpen || cb.consecutiveFailures >= cb.failureThreshold {
+		cb.state = breakerOpen
+		cb.openedAt = time.Now()
+		metrics.SnapshotterBreakerOpen.With(prometheus.Labels{}).Set(1)
+	}
+}
+
+// isOpen reports whether the breaker currently skips snapshot attempts.
+func (cb *circuitBreaker) isOpen() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return cb.state == breakerOpen && time.Since(cb.openedAt) < cb.openWindow
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+)
+
+var _ = Describe("Snapshotter with delta snapshot chain limit", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 2 * time.Second}
+		snapshotterConfig.DeltaSnapshotChainLimit = 2
+	})
+
+	runSnapshotterWithContinuousWrites := func(ssr *Snapshotter, untilCondition func() bool) {
+		cli, err := clientv3.New(clientv3.Config{Endpoints: etcdConnectionConfig.Endpoints})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer cli.Close()
+
+		stopCh := make(chan struct{})
+		runResult := make(chan error)
+		go func() {
+			runResult <- ssr.Run(stopCh, true)
+		}()
+
+		// keep writing keys so that every delta snapshot period collects fresh
+		// events and the delta chain keeps growing
+		keyIndex := 0
+		Eventually(func() bool {
+			_, err := cli.Put(testCtx, fmt.Sprintf("/chain/key-%d", keyIndex), "value")
+			Expect(err).ShouldNot(HaveOccurred())
+			keyIndex++
+			return untilCondition()
+		}, 60*time.Second, time.Second).Should(BeTrue())
+
+		close(stopCh)
+		Eventually(runResult, 30*time.Second).Should(Receive(BeNil()))
+	}
+
+	Context("with action snapshot", func() {
+		It("should take an out-of-schedule full snapshot once the chain limit is exceeded", func() {
+			snapshotterConfig.DeltaSnapshotChainLimitAction = brtypes.DeltaSnapshotChainLimitActionSnapshot
+			ssr, store, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_22.bkp")
+
+			runSnapshotterWithContinuousWrites(ssr, func() bool {
+				return len(listSnapshotsOfKind(store, brtypes.SnapshotKindFull)) >= 2
+			})
+		})
+	})
+
+	Context("with action warn", func() {
+		It("should keep to the scheduled full snapshots and only warn", func() {
+			snapshotterConfig.DeltaSnapshotChainLimitAction = brtypes.DeltaSnapshotChainLimitActionWarn
+			ssr, store, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_23.bkp")
+
+			runSnapshotterWithContinuousWrites(ssr, func() bool {
+				return len(listSnapshotsOfKind(store, brtypes.SnapshotKindDelta)) > 2
+			})
+
+			Expect(listSnapshotsOfKind(store, brtypes.SnapshotKindFull)).Should(HaveLen(1))
+		})
+	})
+})
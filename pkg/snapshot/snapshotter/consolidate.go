@@ -0,0 +1,126 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/etcdutil"
+	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	"go.etcd.io/etcd/pkg/types"
+)
+
+// Consolidate compacts the latest full snapshot and its delta chain into a
+// synthetic full snapshot without contacting the live etcd. The chain is
+// replayed in an embedded etcd in a temporary data directory, the resulting
+// database is uploaded as a new full snapshot and, if requested, the now
+// redundant delta snapshots are deleted from the store. It returns the
+// uploaded snapshot, or nil if there are no delta snapshots to consolidate.
+func (ssr *Snapshotter) Consolidate(ctx context.Context, gcDeltas bool) (*brtypes.Snapshot, error) {
+	if ssr.config.KeyPrefixFilter != "" {
+		// Key-range snapshots do not retain the revision numbers of the original
+		// cluster on restoration, so the synthetic full snapshot cannot be named
+		// consistently with the chain it replaces.
+		return nil, fmt.Errorf("consolidation is not supported with a key prefix filter")
+	}
+
+	fullSnap, deltaSnapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(ssr.store)
+	if err != nil {
+		return nil, err
+	}
+	if fullSnap == nil {
+		return nil, fmt.Errorf("no full snapshot found to consolidate")
+	}
+	if len(deltaSnapList) == 0 {
+		ssr.logger.Infof("No delta snapshots present over full snapshot %s, nothing to consolidate.", fullSnap.SnapName)
+		return nil, nil
+	}
+
+	restoreDir, err := os.MkdirTemp(ssr.snapstoreConfig.TempDir, "consolidate-restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary restoration directory: %v", err)
+	}
+	defer os.RemoveAll(restoreDir)
+
+	restorationConfig := brtypes.NewRestorationConfig()
+	restorationConfig.RestoreDataDir = restoreDir
+	clusterURLsMap, err := types.NewURLsMap(restorationConfig.InitialCluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating url map for restore cluster: %v", err)
+	}
+	peerURLs, err := types.NewURLs(restorationConfig.InitialAdvertisePeerURLs)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing peers urls for restore cluster: %v", err)
+	}
+
+	ssr.logger.Infof("Consolidating full snapshot %s and %d delta snapshot(s) into a synthetic full snapshot...", fullSnap.SnapName, len(deltaSnapList))
+	rs := restorer.NewRestorer(ssr.store, ssr.logger)
+	e, err := rs.Restore(brtypes.RestoreOptions{
+		Config:        restorationConfig,
+		BaseSnapshot:  fullSnap,
+		DeltaSnapList: deltaSnapList,
+		ClusterURLs:   clusterURLsMap,
+		PeerURLs:      peerURLs,
+	}, nil)
+	defer func() {
+		if e != nil {
+			e.Server.Stop()
+			e.Close()
+		}
+	}()
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay the snapshot chain: %v", err)
+	}
+	if e == nil {
+		return nil, fmt.Errorf("replaying the snapshot chain did not leave an embedded etcd to snapshot")
+	}
+
+	compressionSuffix, err := compressor.GetCompressionSuffix(ssr.compressionConfig.Enabled, ssr.compressionConfig.CompressionPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compressionSuffix: %v", err)
+	}
+	clientMaintenance, err := etcdutil.NewFactory(brtypes.EtcdConnectionConfig{
+		Endpoints:         []string{e.Clients[0].Addr().String()},
+		InsecureTransport: true,
+	}).NewMaintenance()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build etcd maintenance client: %v", err)
+	}
+	defer clientMaintenance.Close()
+
+	snapshotCtx, cancel := context.WithTimeout(ctx, ssr.etcdConnectionConfig.SnapshotTimeout.Duration)
+	defer cancel()
+	lastRevision := deltaSnapList[len(deltaSnapList)-1].LastRevision
+	s, err := etcdutil.TakeAndSaveFullSnapshot(snapshotCtx, clientMaintenance, ssr.store, lastRevision, ssr.compressionConfig, compressionSuffix, ssr.snapstoreConfig.ObjectLayout, false, ssr.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save the consolidated full snapshot: %v", err)
+	}
+	ssr.logger.Infof("Successfully saved consolidated full snapshot at: %s", s.SnapName)
+
+	if gcDeltas {
+		for _, deltaSnap := range deltaSnapList {
+			if err := ssr.store.Delete(*deltaSnap); err != nil {
+				ssr.logger.Warnf("Failed to delete consolidated delta snapshot %s: %v", deltaSnap.SnapName, err)
+			}
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	"github.com/gardener/etcd-backup-restore/test/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/pkg/types"
+)
+
+var _ = Describe("Snapshotter consolidation", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		etcdConnectionConfig.ConnectionTimeout.Duration = 10 * time.Second
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 5 * time.Second}
+		snapshotterConfig.GarbageCollectionPeriod = wrappers.Duration{Duration: time.Minute}
+		snapshotterConfig.MaxBackups = brtypes.DefaultMaxBackups
+	})
+
+	It("should produce a synthetic full snapshot that restores to the same state as the chain", func() {
+		ssr, store, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_15.bkp")
+
+		// build a chain of one full snapshot plus periodic delta snapshots
+		populatorCtx, cancelPopulator := context.WithTimeout(testCtx, 15*time.Second)
+		defer cancelPopulator()
+		resp := &utils.EtcdDataPopulationResponse{}
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go utils.PopulateEtcdWithWaitGroup(populatorCtx, wg, logger, etcdConnectionConfig.Endpoints, resp)
+		ssrCtx := utils.ContextWithWaitGroupFollwedByGracePeriod(populatorCtx, wg, 10*time.Second)
+		err := ssr.Run(ssrCtx.Done(), true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Err).ShouldNot(HaveOccurred())
+
+		fullSnap, deltaSnapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(fullSnap).ShouldNot(BeNil())
+		Expect(len(deltaSnapList)).ShouldNot(BeZero())
+
+		s, err := ssr.Consolidate(testCtx, true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(s).ShouldNot(BeNil())
+		Expect(s.Kind).Should(Equal(brtypes.SnapshotKindFull))
+		Expect(s.LastRevision).Should(Equal(deltaSnapList[len(deltaSnapList)-1].LastRevision))
+
+		// the consolidated snapshot replaces the chain as the latest restorable state
+		fullSnap, deltaSnapList, err = miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(fullSnap.SnapName).Should(Equal(s.SnapName))
+		Expect(len(deltaSnapList)).Should(BeZero())
+
+		// restoring the consolidated snapshot yields the state of the original chain
+		restoreDir, err := os.MkdirTemp(outputDir, "consolidate-restore-")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(restoreDir)
+		restorationConfig := brtypes.NewRestorationConfig()
+		restorationConfig.RestoreDataDir = restoreDir
+		clusterURLsMap, err := types.NewURLsMap(restorationConfig.InitialCluster)
+		Expect(err).ShouldNot(HaveOccurred())
+		peerURLs, err := types.NewURLs(restorationConfig.InitialAdvertisePeerURLs)
+		Expect(err).ShouldNot(HaveOccurred())
+		rs := restorer.NewRestorer(store, logger)
+		err = rs.RestoreAndStopEtcd(brtypes.RestoreOptions{
+			Config:       restorationConfig,
+			BaseSnapshot: fullSnap,
+			ClusterURLs:  clusterURLsMap,
+			PeerURLs:     peerURLs,
+		}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		err = utils.CheckDataConsistency(testCtx, restoreDir, resp.KeyTo, logger)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
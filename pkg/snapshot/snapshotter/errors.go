@@ -0,0 +1,60 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"time"
+)
+
+// ErrorKindGC identifies a failed garbage collection operation in the error history.
+const ErrorKindGC = "GC"
+
+// SnapshotError describes a failed snapshotter operation retained for the
+// /snapshot/errors HTTP endpoint.
+type SnapshotError struct {
+	// Time is the time the failure occurred.
+	Time time.Time `json:"time"`
+	// Kind identifies the failed operation: "Full", "Incr" or "GC".
+	Kind string `json:"kind"`
+	// Message is the error message of the failure.
+	Message string `json:"message"`
+}
+
+// recordError retains the given failure in the bounded error history, dropping
+// the oldest entry once the configured limit is reached.
+func (ssr *Snapshotter) recordError(kind string, err error) {
+	limit := int(ssr.config.ErrorHistoryLimit)
+	if limit == 0 {
+		return
+	}
+	ssr.errorsMutex.Lock()
+	defer ssr.errorsMutex.Unlock()
+	ssr.errorHistory = append(ssr.errorHistory, SnapshotError{
+		Time:    time.Now().UTC(),
+		Kind:    kind,
+		Message: err.Error(),
+	})
+	if len(ssr.errorHistory) > limit {
+		ssr.errorHistory = append(ssr.errorHistory[:0:0], ssr.errorHistory[len(ssr.errorHistory)-limit:]...)
+	}
+}
+
+// RecentErrors returns a copy of the most recent snapshot and garbage
+// collection errors, oldest first.
+func (ssr *Snapshotter) RecentErrors() []SnapshotError {
+	ssr.errorsMutex.Lock()
+	defer ssr.errorsMutex.Unlock()
+	return append([]SnapshotError{}, ssr.errorHistory...)
+}
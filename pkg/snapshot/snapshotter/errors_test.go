@@ -0,0 +1,61 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshotter error history", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		// Point the snapshotter at an unreachable endpoint so that snapshots fail.
+		etcdConnectionConfig.Endpoints = []string{"http://localhost:1"}
+		etcdConnectionConfig.ConnectionTimeout.Duration = time.Second
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.FullSnapshotSchedule = "*/1 * * * *"
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 10}
+		snapshotterConfig.ErrorHistoryLimit = 2
+	})
+
+	It("should retain only the most recent snapshot errors", func() {
+		ssr, _, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_16.bkp")
+		Expect(ssr.RecentErrors()).Should(BeEmpty())
+
+		for i := 0; i < 3; i++ {
+			_, err := ssr.TakeFullSnapshotAndResetTimer(false)
+			Expect(err).Should(HaveOccurred())
+		}
+
+		errorHistory := ssr.RecentErrors()
+		Expect(len(errorHistory)).Should(Equal(2))
+		for _, snapErr := range errorHistory {
+			Expect(snapErr.Kind).Should(Equal(brtypes.SnapshotKindFull))
+			Expect(snapErr.Message).ShouldNot(BeEmpty())
+			Expect(snapErr.Time.IsZero()).Should(BeFalse())
+		}
+	})
+})
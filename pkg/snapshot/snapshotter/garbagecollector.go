@@ -17,6 +17,7 @@ package snapshotter
 import (
 	"math"
 	"path"
+	"sort"
 	"time"
 
 	"github.com/gardener/etcd-backup-restore/pkg/metrics"
@@ -27,6 +28,15 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// gcListBatchSize is the maximum number of snapshots fetched from the snapstore
+// in a single backend call while listing snapshots for garbage collection.
+const gcListBatchSize = 1000
+
+// gcIncompleteUploadThresholdAge is the age after which an incomplete multipart
+// upload is considered orphaned and gets aborted by the garbage collector. It is
+// chosen generously so that slow but still running snapshot uploads are not aborted.
+const gcIncompleteUploadThresholdAge = 24 * time.Hour
+
 // RunGarbageCollector basically consider the older backups as garbage and deletes it
 func (ssr *Snapshotter) RunGarbageCollector(stopCh <-chan struct{}) {
 	if ssr.config.GarbageCollectionPeriod.Duration <= time.Second {
@@ -34,32 +44,82 @@ func (ssr *Snapshotter) RunGarbageCollector(stopCh <-chan struct{}) {
 		return
 	}
 
+	// Clean up leftovers of interrupted snapshot uploads once right away, so
+	// that objects left half-written by a crashed predecessor do not linger
+	// until the first garbage collection cycle fires.
+	ssr.cleanupIncompleteUploads()
+
 	for {
 		select {
 		case <-stopCh:
 			ssr.logger.Info("GC: Stop signal received. Closing garbage collector.")
 			return
 		case <-time.After(ssr.config.GarbageCollectionPeriod.Duration):
+			ssr.enterPhase(PhaseGarbageCollection)
+			gcStartTime := time.Now()
 
-			var err error
 			// Update the snapstore object before taking any action on object storage bucket.
 			// Refer: https://github.com/gardener/etcd-backup-restore/issues/422
-			ssr.store, err = snapstore.GetSnapstore(ssr.snapstoreConfig)
-			if err != nil {
+			if err := ssr.refreshSnapstore(); err != nil {
 				ssr.logger.Warnf("GC: Failed to create snapstore from configured storage provider: %v", err)
+				ssr.recordError(ErrorKindGC, err)
+				metrics.GCDurationSeconds.With(prometheus.Labels{metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Observe(time.Since(gcStartTime).Seconds())
+				ssr.enterPhase(PhaseIdle)
 				continue
 			}
 
+			ssr.cleanupIncompleteUploads()
+
 			total := 0
 			ssr.logger.Info("GC: Executing garbage collection...")
-			snapList, err := ssr.store.List()
+			// First pass over the listing, fetched in batches: aggregate only the
+			// full snapshots and their leftover upload chunks, which the retention
+			// policies below operate on, along with the per-kind totals. The delta
+			// snapshot metadata, which dominates the listing of a large snapstore,
+			// is never aggregated; delta snapshots are garbage collected batch by
+			// batch in a second pass.
+			var (
+				fullSnapList  brtypes.SnapList
+				chunkSnapList brtypes.SnapList
+				counts        = map[string]int{
+					brtypes.SnapshotKindFull:  0,
+					brtypes.SnapshotKindDelta: 0,
+					brtypes.SnapshotKindChunk: 0,
+				}
+			)
+			err := snapstore.ListSnapshotsInBatches(ssr.store, gcListBatchSize, func(batch brtypes.SnapList) error {
+				for _, snap := range batch {
+					switch {
+					case snap.IsChunk:
+						counts[brtypes.SnapshotKindChunk]++
+						if snap.Kind == brtypes.SnapshotKindFull {
+							chunkSnapList = append(chunkSnapList, snap)
+						}
+					case snap.Kind == brtypes.SnapshotKindFull:
+						counts[brtypes.SnapshotKindFull]++
+						fullSnapList = append(fullSnapList, snap)
+					default:
+						counts[snap.Kind]++
+					}
+				}
+				return nil
+			})
 			if err != nil {
 				metrics.SnapshotterOperationFailure.With(prometheus.Labels{metrics.LabelError: err.Error()}).Inc()
 				ssr.logger.Warnf("GC: Failed to list snapshots: %v", err)
+				ssr.recordError(ErrorKindGC, err)
+				metrics.GCDurationSeconds.With(prometheus.Labels{metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Observe(time.Since(gcStartTime).Seconds())
+				ssr.enterPhase(PhaseIdle)
 				continue
 			}
+			sort.Sort(fullSnapList)
+			sort.Sort(chunkSnapList)
+			recordSnapshotsTotal(counts)
 
-			snapStreamIndexList := getSnapStreamIndexList(snapList)
+			// Second pass: garbage collect the delta snapshots of all snapstreams
+			// but the latest one.
+			deletedDeltas, deltaGCFailed := ssr.garbageCollectDeltaSnapshots(fullSnapList)
+			total += deletedDeltas
 
 			switch ssr.config.GarbageCollectionPolicy {
 			case brtypes.GarbageCollectionPolicyExponential:
@@ -78,14 +138,13 @@ func (ssr *Snapshotter) RunGarbageCollector(stopCh <-chan struct{}) {
 				)
 				// Here we start processing from second last snapstream, because we want to keep last snapstream
 				// including delta snapshots in it.
-				for snapStreamIndex := len(snapStreamIndexList) - 1; snapStreamIndex > 0; snapStreamIndex-- {
-					snap := snapList[snapStreamIndexList[snapStreamIndex]]
-					nextSnap := snapList[snapStreamIndexList[snapStreamIndex-1]]
-
-					// garbage collect delta snapshots.
-					deletedSnap, err := ssr.garbageCollectDeltaSnapshots(snapList[snapStreamIndexList[snapStreamIndex-1]:snapStreamIndexList[snapStreamIndex]])
-					total += deletedSnap
-					if err != nil {
+				for snapStreamIndex := len(fullSnapList) - 1; snapStreamIndex > 0; snapStreamIndex-- {
+					snap := fullSnapList[snapStreamIndex]
+					nextSnap := fullSnapList[snapStreamIndex-1]
+
+					// Keep the base full snapshot of a snapstream whose delta
+					// snapshots could not all be garbage collected.
+					if deltaGCFailed[snapStreamIndex-1] {
 						continue
 					}
 
@@ -136,42 +195,60 @@ func (ssr *Snapshotter) RunGarbageCollector(stopCh <-chan struct{}) {
 						ssr.logger.Infof("GC: Deleting old full snapshot: %s %v", nextSnap.CreatedOn.UTC(), deleteSnap)
 						if err := ssr.store.Delete(*nextSnap); err != nil {
 							ssr.logger.Warnf("GC: Failed to delete snapshot %s: %v", path.Join(nextSnap.SnapDir, nextSnap.SnapName), err)
+							ssr.recordError(ErrorKindGC, err)
 							metrics.SnapshotterOperationFailure.With(prometheus.Labels{metrics.LabelError: err.Error()}).Inc()
 							metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Inc()
 							continue
 						}
 						metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Inc()
+						metrics.GCSnapshotsDeletedTotal.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull}).Inc()
 						total++
-						garbageCollectChunks(ssr.store, snapList, snapStreamIndexList[snapStreamIndex-1]+1, snapStreamIndexList[snapStreamIndex])
+						low, high := chunkIndexRange(chunkSnapList, nextSnap, snap)
+						garbageCollectChunks(ssr.store, chunkSnapList, low, high)
 					}
 				}
 
 			case brtypes.GarbageCollectionPolicyLimitBased:
-				// Delete delta snapshots in all snapStream but the latest one.
 				// Delete all snapshots beyond limit set by ssr.maxBackups.
-				for snapStreamIndex := 0; snapStreamIndex < len(snapStreamIndexList)-1; snapStreamIndex++ {
-					deletedSnap, err := ssr.garbageCollectDeltaSnapshots(snapList[snapStreamIndexList[snapStreamIndex]:snapStreamIndexList[snapStreamIndex+1]])
-					total += deletedSnap
-					if err != nil {
+				for snapStreamIndex := 0; snapStreamIndex < len(fullSnapList)-1; snapStreamIndex++ {
+					// Keep the base full snapshot of a snapstream whose delta
+					// snapshots could not all be garbage collected.
+					if deltaGCFailed[snapStreamIndex] {
 						continue
 					}
-					if snapStreamIndex < len(snapStreamIndexList)-int(ssr.config.MaxBackups) {
-						snap := snapList[snapStreamIndexList[snapStreamIndex]]
+					if snapStreamIndex < len(fullSnapList)-int(ssr.config.MaxBackups) {
+						snap := fullSnapList[snapStreamIndex]
 						snapPath := path.Join(snap.SnapDir, snap.SnapName)
 						ssr.logger.Infof("GC: Deleting old full snapshot: %s", snapPath)
 						if err := ssr.store.Delete(*snap); err != nil {
 							ssr.logger.Warnf("GC: Failed to delete snapshot %s: %v", snapPath, err)
+							ssr.recordError(ErrorKindGC, err)
 							metrics.SnapshotterOperationFailure.With(prometheus.Labels{metrics.LabelError: err.Error()}).Inc()
 							metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Inc()
 							continue
 						}
 						metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Inc()
+						metrics.GCSnapshotsDeletedTotal.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull}).Inc()
 						total++
-						garbageCollectChunks(ssr.store, snapList, snapStreamIndexList[snapStreamIndex]+1, snapStreamIndexList[snapStreamIndex+1])
+						low, high := chunkIndexRange(chunkSnapList, snap, fullSnapList[snapStreamIndex+1])
+						garbageCollectChunks(ssr.store, chunkSnapList, low, high)
 					}
 				}
 			}
 			ssr.logger.Infof("GC: Total number garbage collected snapshots: %d", total)
+			metrics.GCDurationSeconds.With(prometheus.Labels{metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Observe(time.Since(gcStartTime).Seconds())
+			ssr.enterPhase(PhaseIdle)
+		}
+	}
+}
+
+// cleanupIncompleteUploads aborts leftovers of interrupted snapshot uploads,
+// such as incomplete multipart uploads or staged temporary objects, if the
+// storage backend supports it.
+func (ssr *Snapshotter) cleanupIncompleteUploads() {
+	if store, ok := ssr.store.(brtypes.IncompleteUploadCleanerSnapStore); ok {
+		if err := store.AbortIncompleteUploads(time.Now().Add(-gcIncompleteUploadThresholdAge)); err != nil {
+			ssr.logger.Warnf("GC: Failed to abort incomplete snapshot uploads: %v", err)
 		}
 	}
 }
@@ -210,27 +287,92 @@ func garbageCollectChunks(store brtypes.SnapStore, snapList brtypes.SnapList, lo
 			continue
 		}
 		metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindChunk, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Inc()
+		metrics.GCSnapshotsDeletedTotal.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindChunk}).Inc()
 	}
 }
 
-// garbageCollectDeltaSnapshots deletes only the delta snapshots from revision sorted <snapStream>. It won't delete the full snapshot
-// in snapstream which supposed to be at index 0 in <snapStream>.
-func (ssr *Snapshotter) garbageCollectDeltaSnapshots(snapStream brtypes.SnapList) (int, error) {
+// recordSnapshotsTotal records the current number of snapshots in the store per kind.
+func recordSnapshotsTotal(counts map[string]int) {
+	for kind, count := range counts {
+		metrics.SnapshotsTotal.With(prometheus.Labels{metrics.LabelKind: kind}).Set(float64(count))
+	}
+}
+
+// snapshotSortsBefore reports whether snapshot <a> comes before snapshot <b>
+// in the canonical snapshot ordering of brtypes.SnapList, which snapstream
+// membership is defined by.
+func snapshotSortsBefore(a, b *brtypes.Snapshot) bool {
+	return brtypes.SnapList{a, b}.Less(0, 1)
+}
+
+// chunkIndexRange returns the index range [low, high) in the sorted
+// <chunkSnapList> of the chunk objects uploaded for the snapstream based on the
+// full snapshot <full>, delimited by the base full snapshot <nextFull> of the
+// following snapstream.
+func chunkIndexRange(chunkSnapList brtypes.SnapList, full, nextFull *brtypes.Snapshot) (int, int) {
+	low := sort.Search(len(chunkSnapList), func(i int) bool {
+		return snapshotSortsBefore(full, chunkSnapList[i])
+	})
+	high := sort.Search(len(chunkSnapList), func(i int) bool {
+		return snapshotSortsBefore(nextFull, chunkSnapList[i])
+	})
+	return low, high
+}
+
+// garbageCollectDeltaSnapshots deletes the delta snapshots of all snapstreams but
+// the latest one, processing the store listing batch by batch so that the delta
+// snapshot metadata of a large snapstore is never held in memory at once. A delta
+// snapshot is attributed to the snapstream of the newest full snapshot preceding
+// it in snapshot order. It returns the number of deleted snapshots along with the set of
+// snapstream indexes (into the revision sorted <fullSnapList>) for which a deletion
+// failed; the base full snapshot of such a snapstream must not be garbage collected,
+// as the remaining delta snapshots may still be needed to restore from it.
+func (ssr *Snapshotter) garbageCollectDeltaSnapshots(fullSnapList brtypes.SnapList) (int, map[int]bool) {
 	total := 0
-	for i := len(snapStream) - 1; i > 0; i-- {
-		if (*snapStream[i]).Kind != brtypes.SnapshotKindDelta {
-			continue
+	failedStreams := map[int]bool{}
+	if len(fullSnapList) == 0 {
+		return total, failedStreams
+	}
+	latestFull := fullSnapList[len(fullSnapList)-1]
+	if err := snapstore.ListSnapshotsInBatches(ssr.store, gcListBatchSize, func(batch brtypes.SnapList) error {
+		for _, snap := range batch {
+			if snap.Kind != brtypes.SnapshotKindDelta || snap.IsChunk || snapshotSortsBefore(latestFull, snap) {
+				// Delta snapshots beyond the latest full snapshot form the
+				// latest snapstream and are kept.
+				continue
+			}
+			streamIndex := sort.Search(len(fullSnapList), func(i int) bool {
+				return !snapshotSortsBefore(fullSnapList[i], snap)
+			}) - 1
+			if failedStreams[streamIndex] {
+				// Leave the rest of the snapstream intact once one of its
+				// delta snapshots could not be deleted.
+				continue
+			}
+			snapPath := path.Join(snap.SnapDir, snap.SnapName)
+			ssr.logger.Infof("GC: Deleting old delta snapshot: %s", snapPath)
+			if err := ssr.store.Delete(*snap); err != nil {
+				ssr.logger.Warnf("GC: Failed to delete snapshot %s: %v", snapPath, err)
+				ssr.recordError(ErrorKindGC, err)
+				metrics.SnapshotterOperationFailure.With(prometheus.Labels{metrics.LabelError: err.Error()}).Inc()
+				metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Inc()
+				failedStreams[streamIndex] = true
+				continue
+			}
+			metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Inc()
+			metrics.GCSnapshotsDeletedTotal.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta}).Inc()
+			total++
 		}
-		snapPath := path.Join(snapStream[i].SnapDir, snapStream[i].SnapName)
-		ssr.logger.Infof("GC: Deleting old delta snapshot: %s", snapPath)
-		if err := ssr.store.Delete(*snapStream[i]); err != nil {
-			ssr.logger.Warnf("GC: Failed to delete snapshot %s: %v", snapPath, err)
-			metrics.SnapshotterOperationFailure.With(prometheus.Labels{metrics.LabelError: err.Error()}).Inc()
-			metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Inc()
-			return total, err
+		return nil
+	}); err != nil {
+		ssr.logger.Warnf("GC: Failed to list snapshots for delta snapshot garbage collection: %v", err)
+		ssr.recordError(ErrorKindGC, err)
+		metrics.SnapshotterOperationFailure.With(prometheus.Labels{metrics.LabelError: err.Error()}).Inc()
+		// Without the complete listing it is unknown which snapstreams still
+		// hold delta snapshots, so no full snapshot may be garbage collected.
+		for i := range fullSnapList {
+			failedStreams[i] = true
 		}
-		metrics.GCSnapshotCounter.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Inc()
-		total++
 	}
-	return total, nil
+	return total, failedStreams
 }
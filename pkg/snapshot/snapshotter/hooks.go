@@ -0,0 +1,115 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// snapshotHookPhasePre marks a hook executed before the full snapshot is taken.
+	snapshotHookPhasePre = "pre"
+	// snapshotHookPhasePost marks a hook executed after the full snapshot was attempted.
+	snapshotHookPhasePost = "post"
+
+	// snapshotHookTimeout bounds the execution of a single snapshot hook.
+	snapshotHookTimeout = 30 * time.Second
+)
+
+// snapshotHookData holds the snapshot metadata passed to pre and post snapshot hooks.
+// It is posted as JSON body to webhook hooks and passed as environment variables to
+// command hooks.
+type snapshotHookData struct {
+	Phase        string `json:"phase"`
+	SnapshotName string `json:"snapshotName,omitempty"`
+	LastRevision int64  `json:"lastRevision,omitempty"`
+	Succeeded    bool   `json:"succeeded"`
+}
+
+// runSnapshotHook executes the given hook, interpreting it as a webhook URL if it
+// has an http(s) scheme and as a shell command otherwise. Hook failures are only
+// logged unless the snapshotter is configured to abort on hook failure.
+func (ssr *Snapshotter) runSnapshotHook(hook string, data snapshotHookData) error {
+	if len(hook) == 0 {
+		return nil
+	}
+
+	var err error
+	if isSnapshotWebhook(hook) {
+		err = callSnapshotWebhook(hook, data)
+	} else {
+		err = runSnapshotHookCommand(hook, data)
+	}
+	if err != nil {
+		if ssr.config.AbortOnHookFailure {
+			return fmt.Errorf("%s snapshot hook failed: %v", data.Phase, err)
+		}
+		ssr.logger.Warnf("Ignoring failure of %s snapshot hook: %v", data.Phase, err)
+	}
+	return nil
+}
+
+// isSnapshotWebhook determines whether the given hook is to be treated as webhook URL.
+func isSnapshotWebhook(hook string) bool {
+	return strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://")
+}
+
+// callSnapshotWebhook posts the snapshot metadata as JSON to the given webhook URL.
+func callSnapshotWebhook(url string, data snapshotHookData) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot hook data: %v", err)
+	}
+
+	client := &http.Client{Timeout: snapshotHookTimeout}
+	response, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned status %s", response.Status)
+	}
+	return nil
+}
+
+// runSnapshotHookCommand executes the given command through the shell with the
+// snapshot metadata passed as environment variables.
+func runSnapshotHookCommand(command string, data snapshotHookData) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), snapshotHookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("ETCDBR_HOOK_PHASE=%s", data.Phase),
+		fmt.Sprintf("ETCDBR_SNAPSHOT_NAME=%s", data.SnapshotName),
+		fmt.Sprintf("ETCDBR_SNAPSHOT_REVISION=%d", data.LastRevision),
+		fmt.Sprintf("ETCDBR_SNAPSHOT_SUCCEEDED=%s", strconv.FormatBool(data.Succeeded)),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("command failed: %v, output: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	"github.com/gardener/etcd-backup-restore/test/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot hooks", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		compressionConfig    *compressor.CompressionConfig
+		healthConfig         *brtypes.HealthConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = brtypes.NewEtcdConnectionConfig()
+		etcdConnectionConfig.Endpoints = []string{etcd.Clients[0].Addr().String()}
+		etcdConnectionConfig.ConnectionTimeout.Duration = 5 * time.Second
+		compressionConfig = compressor.NewCompressorConfig()
+		healthConfig = brtypes.NewHealthConfig()
+		snapshotterConfig = &brtypes.SnapshotterConfig{
+			FullSnapshotSchedule:     "*/1 * * * *",
+			DeltaSnapshotPeriod:      wrappers.Duration{Duration: 10},
+			DeltaSnapshotMemoryLimit: brtypes.DefaultDeltaSnapMemoryLimit,
+			GarbageCollectionPeriod:  wrappers.Duration{Duration: 30 * time.Second},
+			GarbageCollectionPolicy:  brtypes.GarbageCollectionPolicyExponential,
+			MaxBackups:               1,
+		}
+		// make sure there are events to be snapshotted
+		utils.PopulateEtcd(testCtx, logger, etcdConnectionConfig.Endpoints, 0, 2, nil)
+	})
+
+	Context("with command hooks configured", func() {
+		It("should pass the snapshot metadata to the hook command", func() {
+			hookOutputFile := path.Join(outputDir, "snapshot-hook.out")
+			defer os.Remove(hookOutputFile)
+
+			snapstoreConfig := &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_8.bkp")}
+			store, err := snapstore.GetSnapstore(snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			snapshotterConfig.PreSnapshotHook = fmt.Sprintf("echo \"$ETCDBR_HOOK_PHASE\" >> %s", hookOutputFile)
+			snapshotterConfig.PostSnapshotHook = fmt.Sprintf("echo \"$ETCDBR_HOOK_PHASE $ETCDBR_SNAPSHOT_NAME $ETCDBR_SNAPSHOT_SUCCEEDED\" >> %s", hookOutputFile)
+			snapshotterConfig.AbortOnHookFailure = true
+
+			ssr, err := NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, healthConfig, snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			s, err := ssr.TakeFullSnapshotAndResetTimer(false)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			content, err := os.ReadFile(hookOutputFile)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(content)).Should(ContainSubstring("pre\n"))
+			Expect(string(content)).Should(ContainSubstring(fmt.Sprintf("post %s true\n", s.SnapName)))
+		})
+	})
+
+	Context("with a webhook configured", func() {
+		It("should post the snapshot metadata as JSON", func() {
+			var receivedBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				receivedBody, _ = io.ReadAll(req.Body)
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			snapstoreConfig := &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_9.bkp")}
+			store, err := snapstore.GetSnapstore(snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			snapshotterConfig.PostSnapshotHook = server.URL
+			snapshotterConfig.AbortOnHookFailure = true
+
+			ssr, err := NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, healthConfig, snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			s, err := ssr.TakeFullSnapshotAndResetTimer(false)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			hookData := map[string]interface{}{}
+			Expect(json.Unmarshal(receivedBody, &hookData)).ShouldNot(HaveOccurred())
+			Expect(hookData["phase"]).Should(Equal("post"))
+			Expect(hookData["snapshotName"]).Should(Equal(s.SnapName))
+			Expect(hookData["succeeded"]).Should(Equal(true))
+		})
+	})
+
+	Context("with a failing pre snapshot hook", func() {
+		It("should abort the snapshot when configured to abort on hook failure", func() {
+			snapstoreConfig := &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_10.bkp")}
+			store, err := snapstore.GetSnapstore(snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			snapshotterConfig.PreSnapshotHook = "exit 1"
+			snapshotterConfig.AbortOnHookFailure = true
+
+			ssr, err := NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, healthConfig, snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = ssr.TakeFullSnapshotAndResetTimer(false)
+			Expect(err).Should(HaveOccurred())
+			list, err := store.List()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(len(list)).Should(BeZero())
+		})
+
+		It("should take the snapshot when hook failures are ignored", func() {
+			snapstoreConfig := &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_11.bkp")}
+			store, err := snapstore.GetSnapstore(snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			snapshotterConfig.PreSnapshotHook = "exit 1"
+
+			ssr, err := NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, healthConfig, snapstoreConfig)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = ssr.TakeFullSnapshotAndResetTimer(false)
+			Expect(err).ShouldNot(HaveOccurred())
+			list, err := store.List()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(len(list)).Should(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/etcdutil/client"
+	mockfactory "github.com/gardener/etcd-backup-restore/pkg/mock/etcdutil/client"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+)
+
+var _ = Describe("Snapshotter leader-only snapshots", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		etcdConnectionConfig.ConnectionTimeout.Duration = 10 * time.Second
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.FullSnapshotSchedule = "*/1 * * * *"
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 10}
+		snapshotterConfig.GarbageCollectionPeriod = wrappers.Duration{Duration: time.Minute}
+		snapshotterConfig.MaxBackups = brtypes.DefaultMaxBackups
+		snapshotterConfig.SnapshotOnlyIfLeader = true
+	})
+
+	It("should take the full snapshot when the local member is the leader", func() {
+		// The suite runs a single-member embedded etcd, so the local member
+		// is always the leader.
+		ssr, _, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_19.bkp")
+
+		s, err := ssr.TakeFullSnapshotAndResetTimer(false)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(s).ShouldNot(BeNil())
+		Expect(s.Kind).Should(Equal(brtypes.SnapshotKindFull))
+	})
+
+	It("should skip the full snapshot and reset the timer when the local member is a follower", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		defer ctrl.Finish()
+
+		// Report the local member as a follower through a mocked maintenance
+		// client, since the single-member embedded etcd is always the leader.
+		cm := mockfactory.NewMockMaintenanceCloser(ctrl)
+		cm.EXPECT().Status(gomock.Any(), etcdConnectionConfig.Endpoints[0]).Return(&clientv3.StatusResponse{
+			Header: &etcdserverpb.ResponseHeader{MemberId: 2},
+			Leader: 1,
+		}, nil)
+		cm.EXPECT().Close().Return(nil)
+		factory := mockfactory.NewMockFactory(ctrl)
+		factory.EXPECT().NewMaintenance().Return(cm, nil)
+
+		ssr, store, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_27.bkp")
+		ssr.NewClientFactory = func(cfg brtypes.EtcdConnectionConfig, opts ...client.Option) client.Factory {
+			return factory
+		}
+
+		s, err := ssr.TakeFullSnapshotAndResetTimer(false)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(s).Should(BeNil())
+
+		// no snapshot must have been saved and the timer must be reset
+		list, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list).Should(BeEmpty())
+		Expect(ssr.State().NextFullSnapshotAt.After(time.Now())).Should(BeTrue())
+	})
+})
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+)
+
+// livenessGracePeriod is the slack added on top of the expected progress
+// window before the snapshot loop is considered stuck.
+const livenessGracePeriod = 2 * time.Minute
+
+// recordLoopActivity records that the snapshot event loop has made progress.
+func (ssr *Snapshotter) recordLoopActivity() {
+	ssr.stateMutex.Lock()
+	defer ssr.stateMutex.Unlock()
+	ssr.lastLoopActivity = time.Now().UTC()
+}
+
+// IsAlive reports whether the snapshot loop is alive and making progress
+// within its expected window. A snapshotter that has not been started yet is
+// considered alive, one that has been stopped is not. While a snapshot or
+// garbage collection is in progress the phase entry time bounded by the
+// respective timeout is used, otherwise the loop is expected to have woken up
+// at least once per delta snapshot period, or, with delta snapshotting
+// disabled, before the next scheduled full snapshot.
+func (ssr *Snapshotter) IsAlive() bool {
+	ssr.stateMutex.Lock()
+	defer ssr.stateMutex.Unlock()
+
+	now := time.Now().UTC()
+	switch ssr.state.Phase {
+	case PhaseStopped:
+		return false
+	case PhaseFullSnapshot, PhaseDeltaSnapshot:
+		return now.Sub(ssr.state.PhaseEnteredAt) < ssr.etcdConnectionConfig.SnapshotTimeout.Duration+livenessGracePeriod
+	case PhaseGarbageCollection:
+		return now.Sub(ssr.state.PhaseEnteredAt) < ssr.config.GarbageCollectionPeriod.Duration+livenessGracePeriod
+	}
+
+	if ssr.lastLoopActivity.IsZero() {
+		// The snapshot loop has not been started yet.
+		return true
+	}
+	if ssr.config.DeltaSnapshotPeriod.Duration >= brtypes.DeltaSnapshotIntervalThreshold {
+		return now.Sub(ssr.lastLoopActivity) < ssr.config.DeltaSnapshotPeriod.Duration+livenessGracePeriod
+	}
+	if ssr.state.NextFullSnapshotAt.IsZero() {
+		return true
+	}
+	return now.Before(ssr.state.NextFullSnapshotAt.Add(livenessGracePeriod))
+}
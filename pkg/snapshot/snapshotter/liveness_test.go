@@ -0,0 +1,54 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshotter liveness", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		// Point the snapshotter at an unreachable endpoint so that the
+		// snapshot loop terminates quickly.
+		etcdConnectionConfig.Endpoints = []string{"http://localhost:1"}
+		etcdConnectionConfig.ConnectionTimeout.Duration = time.Second
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.FullSnapshotSchedule = "*/1 * * * *"
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 10}
+	})
+
+	It("should report alive before the snapshot loop starts and dead once it has stopped", func() {
+		ssr, _, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_17.bkp")
+		Expect(ssr.IsAlive()).Should(BeTrue())
+
+		// The initial full snapshot fails against the unreachable etcd,
+		// terminating the run and stopping the snapshotter.
+		err := ssr.Run(make(chan struct{}), true)
+		Expect(err).Should(HaveOccurred())
+		Expect(ssr.IsAlive()).Should(BeFalse())
+	})
+})
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"fmt"
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+)
+
+var _ = Describe("Latest snapshot metadata under concurrent access", func() {
+	It("should serve consistent metadata while snapshots are being taken", func() {
+		etcdConnectionConfig := newTestEtcdConnectionConfig()
+		snapshotterConfig := newTestSnapshotterConfig()
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: time.Second}
+		ssr, _, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_26.bkp")
+
+		stopCh := make(chan struct{})
+		runResult := make(chan error)
+		go func() {
+			runResult <- ssr.Run(stopCh, true)
+		}()
+
+		// hammer the metadata accessor from a second goroutine while the
+		// snapshot loop keeps updating it, so that `go test -race` catches any
+		// unsynchronized access
+		readerStopCh := make(chan struct{})
+		readerDoneCh := make(chan struct{})
+		go func() {
+			defer close(readerDoneCh)
+			for {
+				select {
+				case <-readerStopCh:
+					return
+				default:
+					fullSnapshot, deltaSnapshots := ssr.GetLatestSnapshotMetadata()
+					for _, snap := range deltaSnapshots {
+						Expect(snap.Kind).Should(Equal(brtypes.SnapshotKindDelta))
+					}
+					_ = fullSnapshot
+				}
+			}
+		}()
+
+		cli, err := clientv3.New(clientv3.Config{Endpoints: etcdConnectionConfig.Endpoints})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer cli.Close()
+		for keyIndex := 0; keyIndex < 8; keyIndex++ {
+			_, err = cli.Put(testCtx, fmt.Sprintf("/metadata/key-%d", keyIndex), "value")
+			Expect(err).ShouldNot(HaveOccurred())
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		Eventually(func() int {
+			_, deltaSnapshots := ssr.GetLatestSnapshotMetadata()
+			return len(deltaSnapshots)
+		}, 30*time.Second, time.Second).Should(BeNumerically(">=", 1))
+
+		close(readerStopCh)
+		Eventually(readerDoneCh, 10*time.Second).Should(BeClosed())
+		close(stopCh)
+		Eventually(runResult, 30*time.Second).Should(Receive(BeNil()))
+
+		fullSnapshot, _ := ssr.GetLatestSnapshotMetadata()
+		Expect(fullSnapshot).ShouldNot(BeNil())
+	})
+})
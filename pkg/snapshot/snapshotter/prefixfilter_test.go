@@ -0,0 +1,143 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"crypto/sha256"
+	"io"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/deltacodec"
+	"github.com/gardener/etcd-backup-restore/pkg/etcdutil"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+)
+
+var _ = Describe("Snapshotter with multiple key prefix filters", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 2 * time.Second}
+		snapshotterConfig.KeyPrefixFilter = "/app/,/svc/"
+	})
+
+	It("should keep keys outside the prefixes out of full and delta snapshots", func() {
+		cli, err := clientv3.New(clientv3.Config{Endpoints: etcdConnectionConfig.Endpoints})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer cli.Close()
+		for _, key := range []string{"/app/base", "/svc/base", "/other/base"} {
+			_, err = cli.Put(testCtx, key, "value")
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		ssr, store, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_21.bkp")
+
+		stopCh := make(chan struct{})
+		runResult := make(chan error)
+		go func() {
+			runResult <- ssr.Run(stopCh, true)
+		}()
+
+		// wait for the initial full snapshot before writing the keys that the
+		// delta snapshots are expected to capture
+		Eventually(func() int {
+			list, err := store.List()
+			if err != nil {
+				return 0
+			}
+			return len(list)
+		}, 30*time.Second, time.Second).Should(BeNumerically(">=", 1))
+
+		for _, key := range []string{"/app/delta", "/svc/delta", "/other/delta"} {
+			_, err = cli.Put(testCtx, key, "value")
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		Eventually(func() int {
+			return len(listSnapshotsOfKind(store, brtypes.SnapshotKindDelta))
+		}, 30*time.Second, time.Second).Should(BeNumerically(">=", 1))
+		close(stopCh)
+		Eventually(runResult, 30*time.Second).Should(Receive(BeNil()))
+
+		fullSnaps := listSnapshotsOfKind(store, brtypes.SnapshotKindFull)
+		Expect(fullSnaps).Should(HaveLen(1))
+		fullKeys := decodeKeyRangeSnapshotKeys(store, fullSnaps[0])
+		Expect(fullKeys).Should(ContainElement("/app/base"))
+		Expect(fullKeys).Should(ContainElement("/svc/base"))
+		for _, key := range fullKeys {
+			Expect(key).ShouldNot(HavePrefix("/other/"))
+		}
+
+		var deltaKeys []string
+		for _, snap := range listSnapshotsOfKind(store, brtypes.SnapshotKindDelta) {
+			deltaKeys = append(deltaKeys, decodeDeltaSnapshotKeys(store, snap)...)
+		}
+		Expect(deltaKeys).Should(ContainElement("/app/delta"))
+		Expect(deltaKeys).Should(ContainElement("/svc/delta"))
+		Expect(deltaKeys).ShouldNot(ContainElement("/other/delta"))
+	})
+})
+
+func listSnapshotsOfKind(store brtypes.SnapStore, kind string) brtypes.SnapList {
+	list, err := store.List()
+	Expect(err).ShouldNot(HaveOccurred())
+	var snaps brtypes.SnapList
+	for _, snap := range list {
+		if snap.Kind == kind {
+			snaps = append(snaps, snap)
+		}
+	}
+	return snaps
+}
+
+func decodeKeyRangeSnapshotKeys(store brtypes.SnapStore, snap *brtypes.Snapshot) []string {
+	rc, err := store.Fetch(*snap)
+	Expect(err).ShouldNot(HaveOccurred())
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	Expect(err).ShouldNot(HaveOccurred())
+	events, err := etcdutil.DecodeKeyRangeSnapshot(data)
+	Expect(err).ShouldNot(HaveOccurred())
+	var keys []string
+	for _, event := range events {
+		keys = append(keys, string(event.EtcdEvent.Kv.Key))
+	}
+	return keys
+}
+
+func decodeDeltaSnapshotKeys(store brtypes.SnapStore, snap *brtypes.Snapshot) []string {
+	rc, err := store.Fetch(*snap)
+	Expect(err).ShouldNot(HaveOccurred())
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(len(data)).Should(BeNumerically(">", sha256.Size))
+	events, err := deltacodec.DecodeEvents(data[:len(data)-sha256.Size])
+	Expect(err).ShouldNot(HaveOccurred())
+	var keys []string
+	for _, event := range events {
+		keys = append(keys, string(event.EtcdEvent.Kv.Key))
+	}
+	return keys
+}
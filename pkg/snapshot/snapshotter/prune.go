@@ -0,0 +1,95 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/gardener/etcd-backup-restore/pkg/metrics"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PruneSnapshotsBeforeRevision deletes all snapshots from the store whose
+// entire revision range lies below the given revision, typically after an etcd
+// compaction has made restoring to those revisions pointless. The latest
+// snapstream - the full snapshot and delta snapshots needed to restore to the
+// current state - is always preserved, and older snapstreams are only removed
+// as a whole so that no remaining chain is ever broken. It returns the number
+// of full and delta snapshots deleted.
+func (ssr *Snapshotter) PruneSnapshotsBeforeRevision(beforeRevision int64) (int, int, error) {
+	// Update the snapstore object before taking any action on object storage bucket.
+	// Refer: https://github.com/gardener/etcd-backup-restore/issues/422
+	if err := ssr.refreshSnapstore(); err != nil {
+		return 0, 0, fmt.Errorf("failed to create snapstore from configured storage provider: %v", err)
+	}
+
+	var snapList brtypes.SnapList
+	err := snapstore.ListSnapshotsInBatches(ssr.store, gcListBatchSize, func(batch brtypes.SnapList) error {
+		snapList = append(snapList, batch...)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list snapshots: %v", err)
+	}
+	sort.Sort(snapList)
+
+	snapStreamIndexList := getSnapStreamIndexList(snapList)
+
+	fullDeleted, deltaDeleted := 0, 0
+	// The last snapstream is the chain needed to restore to the current state,
+	// so it is never pruned, irrespective of the given revision.
+	for snapStreamIndex := 0; snapStreamIndex < len(snapStreamIndexList)-1; snapStreamIndex++ {
+		snapStream := snapList[snapStreamIndexList[snapStreamIndex]:snapStreamIndexList[snapStreamIndex+1]]
+		if !snapStreamBelowRevision(snapStream, beforeRevision) {
+			continue
+		}
+		// Delete the snapstream back to front so that a partial failure never
+		// leaves delta snapshots behind without their base full snapshot.
+		for i := len(snapStream) - 1; i >= 0; i-- {
+			snap := snapStream[i]
+			snapPath := path.Join(snap.SnapDir, snap.SnapName)
+			ssr.logger.Infof("Prune: Deleting snapshot below revision %d: %s", beforeRevision, snapPath)
+			if err := ssr.store.Delete(*snap); err != nil {
+				metrics.SnapshotterOperationFailure.With(prometheus.Labels{metrics.LabelError: err.Error()}).Inc()
+				return fullDeleted, deltaDeleted, fmt.Errorf("failed to delete snapshot %s: %v", snapPath, err)
+			}
+			switch {
+			case snap.IsChunk:
+			case snap.Kind == brtypes.SnapshotKindFull:
+				fullDeleted++
+			case snap.Kind == brtypes.SnapshotKindDelta:
+				deltaDeleted++
+			}
+		}
+	}
+	ssr.logger.Infof("Prune: Deleted %d full and %d delta snapshot(s) below revision %d.", fullDeleted, deltaDeleted, beforeRevision)
+	return fullDeleted, deltaDeleted, nil
+}
+
+// snapStreamBelowRevision reports whether every snapshot of the given
+// snapstream covers only revisions below the given revision.
+func snapStreamBelowRevision(snapStream brtypes.SnapList, revision int64) bool {
+	for _, snap := range snapStream {
+		if snap.LastRevision >= revision {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pruning snapshots below a revision", func() {
+	var (
+		store brtypes.SnapStore
+		ssr   *Snapshotter
+	)
+
+	// populateStoreForPruning saves two snapstreams: an older one covering
+	// revisions up to 300 and the latest one covering revisions up to 400.
+	populateStoreForPruning := func(container string) {
+		var err error
+		snapstoreConfig := &brtypes.SnapstoreConfig{Container: path.Join(outputDir, container)}
+		store, err = snapstore.GetSnapstore(snapstoreConfig)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		createdOn := time.Now().Add(-time.Hour)
+		for _, s := range []struct {
+			kind          string
+			startRevision int64
+			lastRevision  int64
+		}{
+			{brtypes.SnapshotKindFull, 0, 100},
+			{brtypes.SnapshotKindDelta, 101, 200},
+			{brtypes.SnapshotKindDelta, 201, 300},
+			{brtypes.SnapshotKindFull, 0, 350},
+			{brtypes.SnapshotKindDelta, 351, 400},
+		} {
+			snap := brtypes.Snapshot{
+				Kind:          s.kind,
+				CreatedOn:     createdOn,
+				StartRevision: s.startRevision,
+				LastRevision:  s.lastRevision,
+			}
+			snap.GenerateSnapshotName()
+			createdOn = createdOn.Add(10 * time.Minute)
+			Expect(store.Save(snap, io.NopCloser(strings.NewReader(fmt.Sprintf("dummy-snapshot-content for snap created on %s", snap.CreatedOn))))).Should(Succeed())
+		}
+
+		// the snapshotter is created after the store is populated so that it
+		// starts out with the saved snapstreams as its latest snapshots
+		snapshotterConfig := newTestSnapshotterConfig()
+		snapshotterConfig.FullSnapshotSchedule = brtypes.DefaultFullSnapshotSchedule
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 10 * time.Second}
+		snapshotterConfig.MaxBackups = brtypes.DefaultMaxBackups
+		ssr, _, _ = newTestSnapshotter(snapshotterConfig, newTestEtcdConnectionConfig(), container)
+	}
+
+	Context("when the older snapstream lies entirely below the revision", func() {
+		It("should delete the older snapstream and preserve the latest one", func() {
+			populateStoreForPruning("snapshotter_24.bkp")
+
+			fullDeleted, deltaDeleted, err := ssr.PruneSnapshotsBeforeRevision(1000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fullDeleted).Should(Equal(1))
+			Expect(deltaDeleted).Should(Equal(2))
+
+			list, err := store.List()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list).Should(HaveLen(2))
+			Expect(list[0].Kind).Should(Equal(brtypes.SnapshotKindFull))
+			Expect(list[0].LastRevision).Should(Equal(int64(350)))
+			Expect(list[1].Kind).Should(Equal(brtypes.SnapshotKindDelta))
+			Expect(list[1].LastRevision).Should(Equal(int64(400)))
+		})
+	})
+
+	Context("when a snapshot of the older snapstream reaches the revision", func() {
+		It("should not delete any snapshot of that snapstream", func() {
+			populateStoreForPruning("snapshotter_25.bkp")
+
+			fullDeleted, deltaDeleted, err := ssr.PruneSnapshotsBeforeRevision(250)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fullDeleted).Should(BeZero())
+			Expect(deltaDeleted).Should(BeZero())
+
+			list, err := store.List()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list).Should(HaveLen(5))
+		})
+	})
+})
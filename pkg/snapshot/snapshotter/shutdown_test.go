@@ -0,0 +1,80 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	"github.com/gardener/etcd-backup-restore/test/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Final delta snapshot on shutdown", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: time.Minute} // won't elapse within the test timeout
+		snapshotterConfig.FinalDeltaSnapshotOnShutdown = true
+	})
+
+	It("should flush the accumulated events as a trailing delta snapshot", func() {
+		ssr, store, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_20.bkp")
+
+		stopCh := make(chan struct{})
+		runResult := make(chan error)
+		go func() {
+			runResult <- ssr.Run(stopCh, true)
+		}()
+
+		// wait for the initial full snapshot before writing the keys that the
+		// trailing delta snapshot is expected to capture
+		Eventually(func() int {
+			list, err := store.List()
+			if err != nil {
+				return 0
+			}
+			return len(list)
+		}, 30*time.Second, time.Second).Should(BeNumerically(">=", 1))
+
+		resp := &utils.EtcdDataPopulationResponse{}
+		utils.PopulateEtcd(testCtx, logger, etcdConnectionConfig.Endpoints, 0, 5, resp)
+		Expect(resp.Err).ShouldNot(HaveOccurred())
+
+		// give the watch time to deliver the events before shutting down
+		time.Sleep(2 * time.Second)
+		close(stopCh)
+		Eventually(runResult, 30*time.Second).Should(Receive(BeNil()))
+
+		list, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		var trailingDelta *brtypes.Snapshot
+		for _, snap := range list {
+			if snap.Kind == brtypes.SnapshotKindDelta {
+				trailingDelta = snap
+			}
+		}
+		Expect(trailingDelta).ShouldNot(BeNil())
+		Expect(trailingDelta.LastRevision).Should(BeNumerically(">=", resp.EndRevision))
+	})
+})
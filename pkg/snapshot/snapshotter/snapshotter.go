@@ -18,14 +18,15 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"path"
 	"sync"
 	"time"
 
 	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/deltacodec"
 	"github.com/gardener/etcd-backup-restore/pkg/errors"
 	"github.com/gardener/etcd-backup-restore/pkg/etcdutil"
 	"github.com/gardener/etcd-backup-restore/pkg/health/heartbeat"
@@ -42,17 +43,25 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// snapListCacheTTL is the duration for which the snapshotter serves snapshot
+// listings from its cache before listing the snapstore backend again. It is
+// kept short so that snapshots taken by other actors show up quickly.
+const snapListCacheTTL = 30 * time.Second
+
+// finalDeltaSnapshotGracePeriod bounds the final delta snapshot taken on
+// shutdown, so that a slow or unreachable snapstore cannot hang the shutdown
+// indefinitely.
+const finalDeltaSnapshotGracePeriod = 30 * time.Second
+
 var (
 	emptyStruct   struct{}
 	snapstoreHash = make(map[string]interface{})
+	// scheduleJitterFraction is the random but per-process stable fraction of the
+	// configured schedule jitter by which scheduled full snapshots are delayed,
+	// so that replicas sharing the same schedule do not fire simultaneously.
+	scheduleJitterFraction = rand.Float64()
 )
 
-// event is wrapper over etcd event to keep track of time of event
-type event struct {
-	EtcdEvent *clientv3.Event `json:"etcdEvent"`
-	Time      time.Time       `json:"time"`
-}
-
 type result struct {
 	Snapshot *brtypes.Snapshot `json:"snapshot"`
 	Err      error             `json:"error"`
@@ -61,12 +70,15 @@ type result struct {
 // NewSnapshotterConfig returns the snapshotter config.
 func NewSnapshotterConfig() *brtypes.SnapshotterConfig {
 	return &brtypes.SnapshotterConfig{
-		FullSnapshotSchedule:     brtypes.DefaultFullSnapshotSchedule,
-		DeltaSnapshotPeriod:      wrappers.Duration{Duration: brtypes.DefaultDeltaSnapshotInterval},
-		DeltaSnapshotMemoryLimit: brtypes.DefaultDeltaSnapMemoryLimit,
-		GarbageCollectionPeriod:  wrappers.Duration{Duration: brtypes.DefaultGarbageCollectionPeriod},
-		GarbageCollectionPolicy:  brtypes.GarbageCollectionPolicyExponential,
-		MaxBackups:               brtypes.DefaultMaxBackups,
+		FullSnapshotSchedule:          brtypes.DefaultFullSnapshotSchedule,
+		DeltaSnapshotPeriod:           wrappers.Duration{Duration: brtypes.DefaultDeltaSnapshotInterval},
+		DeltaSnapshotMemoryLimit:      brtypes.DefaultDeltaSnapMemoryLimit,
+		DeltaSnapshotEncoding:         brtypes.DeltaSnapshotEncodingJSON,
+		GarbageCollectionPeriod:       wrappers.Duration{Duration: brtypes.DefaultGarbageCollectionPeriod},
+		GarbageCollectionPolicy:       brtypes.GarbageCollectionPolicyExponential,
+		MaxBackups:                    brtypes.DefaultMaxBackups,
+		ErrorHistoryLimit:             brtypes.DefaultErrorHistoryLimit,
+		DeltaSnapshotChainLimitAction: brtypes.DeltaSnapshotChainLimitActionWarn,
 	}
 }
 
@@ -79,24 +91,41 @@ type Snapshotter struct {
 	compressionConfig    *compressor.CompressionConfig
 	healthConfig         *brtypes.HealthConfig
 	schedule             cron.Schedule
+	scheduleJitterOffset time.Duration
 	prevSnapshot         *brtypes.Snapshot
-	PrevFullSnapshot     *brtypes.Snapshot
-	PrevDeltaSnapshots   brtypes.SnapList
-	fullSnapshotReqCh    chan bool
-	deltaSnapshotReqCh   chan struct{}
-	fullSnapshotAckCh    chan result
-	deltaSnapshotAckCh   chan result
-	fullSnapshotTimer    *time.Timer
-	deltaSnapshotTimer   *time.Timer
-	events               []byte
-	watchCh              clientv3.WatchChan
-	etcdWatchClient      *clientv3.Watcher
-	cancelWatch          context.CancelFunc
-	SsrStateMutex        *sync.Mutex
-	SsrState             brtypes.SnapshotterState
-	lastEventRevision    int64
-	K8sClientset         client.Client
-	snapstoreConfig      *brtypes.SnapstoreConfig
+	// prevSnapshotsMutex guards PrevFullSnapshot and PrevDeltaSnapshots, which
+	// the snapshot loop updates while HTTP handlers read them concurrently via
+	// GetLatestSnapshotMetadata.
+	prevSnapshotsMutex sync.RWMutex
+	PrevFullSnapshot   *brtypes.Snapshot
+	PrevDeltaSnapshots brtypes.SnapList
+	fullSnapshotReqCh  chan bool
+	deltaSnapshotReqCh chan struct{}
+	fullSnapshotAckCh  chan result
+	deltaSnapshotAckCh chan result
+	fullSnapshotTimer  *time.Timer
+	deltaSnapshotTimer *time.Timer
+	events             []byte
+	watchCh            clientv3.WatchChan
+	etcdWatchClient    *clientv3.Watcher
+	cancelWatch        context.CancelFunc
+	SsrStateMutex      *sync.Mutex
+	SsrState           brtypes.SnapshotterState
+	lastEventRevision  int64
+	eventCount         uint
+	deltaCodec         deltacodec.Codec
+	K8sClientset       client.Client
+	// NewClientFactory allows to define how to create the etcd clients used by
+	// the snapshotter; when unset, the default TLS client factory is used.
+	NewClientFactory brtypes.NewClientFactoryFunc
+	snapstoreConfig  *brtypes.SnapstoreConfig
+	state            State
+	stateMutex       sync.Mutex
+	lastLoopActivity time.Time
+	breaker          *circuitBreaker
+	configMutex      sync.Mutex
+	errorHistory     []SnapshotError
+	errorsMutex      sync.Mutex
 }
 
 // NewSnapshotter returns the snapshotter object.
@@ -107,6 +136,21 @@ func NewSnapshotter(logger *logrus.Entry, config *brtypes.SnapshotterConfig, sto
 		return nil, fmt.Errorf("invalid schedule provied %s : %v", config.FullSnapshotSchedule, err)
 	}
 
+	deltaCodec, err := deltacodec.NewCodec(config.DeltaSnapshotEncoding)
+	if err != nil {
+		// Ideally this should be validated before.
+		return nil, fmt.Errorf("invalid delta snapshot encoding provided %s : %v", config.DeltaSnapshotEncoding, err)
+	}
+
+	// Throttle uploads before caching so that snapshot transfers do not starve
+	// the etcd peer traffic.
+	if config.UploadBytesPerSecond > 0 {
+		store = snapstore.NewThrottledSnapStore(store, config.UploadBytesPerSecond, 0)
+	}
+	// Cache the snapshot listing so that the garbage collector and HTTP
+	// handlers do not re-list the snapstore backend on every read.
+	store = snapstore.NewCachingSnapStore(store, snapListCacheTTL)
+
 	var prevSnapshot *brtypes.Snapshot
 	fullSnap, deltaSnapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
 	if err != nil {
@@ -144,19 +188,26 @@ func NewSnapshotter(logger *logrus.Entry, config *brtypes.SnapshotterConfig, sto
 		compressionConfig:    compressionConfig,
 		healthConfig:         healthConfig,
 
-		schedule:           sdl,
-		prevSnapshot:       prevSnapshot,
-		PrevFullSnapshot:   fullSnap,
-		PrevDeltaSnapshots: deltaSnapList,
-		SsrState:           brtypes.SnapshotterInactive,
-		SsrStateMutex:      &sync.Mutex{},
-		fullSnapshotReqCh:  make(chan bool),
-		deltaSnapshotReqCh: make(chan struct{}),
-		fullSnapshotAckCh:  make(chan result),
-		deltaSnapshotAckCh: make(chan result),
-		cancelWatch:        func() {},
-		K8sClientset:       clientSet,
-		snapstoreConfig:    storeConfig,
+		schedule:             sdl,
+		scheduleJitterOffset: time.Duration(scheduleJitterFraction * float64(config.ScheduleJitter.Duration)),
+		prevSnapshot:         prevSnapshot,
+		PrevFullSnapshot:     fullSnap,
+		PrevDeltaSnapshots:   deltaSnapList,
+		SsrState:             brtypes.SnapshotterInactive,
+		SsrStateMutex:        &sync.Mutex{},
+		fullSnapshotReqCh:    make(chan bool),
+		deltaSnapshotReqCh:   make(chan struct{}),
+		fullSnapshotAckCh:    make(chan result),
+		deltaSnapshotAckCh:   make(chan result),
+		cancelWatch:          func() {},
+		K8sClientset:         clientSet,
+		snapstoreConfig:      storeConfig,
+		deltaCodec:           deltaCodec,
+		breaker:              newCircuitBreaker(config.SnapshotFailureThreshold, config.BreakerOpenWindow.Duration),
+		state: State{
+			Phase:          PhaseIdle,
+			PhaseEnteredAt: time.Now().UTC(),
+		},
 	}, nil
 }
 
@@ -192,6 +243,12 @@ func (ssr *Snapshotter) Run(stopCh <-chan struct{}, startWithFullSnapshot bool)
 		ssr.deltaSnapshotTimer.Reset(ssr.config.DeltaSnapshotPeriod.Duration)
 	}
 
+	if ssr.config.BackupValidationPeriod.Duration > 0 {
+		validationCtx, cancelValidation := context.WithCancel(context.TODO())
+		defer cancelValidation()
+		go ssr.runBackupValidation(validationCtx)
+	}
+
 	return ssr.snapshotEventHandler(stopCh)
 }
 
@@ -245,6 +302,7 @@ func (ssr *Snapshotter) stop() {
 
 	ssr.SsrState = brtypes.SnapshotterInactive
 	ssr.SsrStateMutex.Unlock()
+	ssr.enterPhase(PhaseStopped)
 }
 
 func (ssr *Snapshotter) closeEtcdClient() {
@@ -267,36 +325,94 @@ func (ssr *Snapshotter) closeEtcdClient() {
 // TakeFullSnapshotAndResetTimer takes a full snapshot and resets the full snapshot
 // timer as per the schedule.
 func (ssr *Snapshotter) TakeFullSnapshotAndResetTimer(isFinal bool) (*brtypes.Snapshot, error) {
+	if !ssr.breaker.allowAttempt() {
+		ssr.logger.Warnf("Circuit breaker is open, skipping scheduled full snapshot.")
+		return nil, ssr.resetFullSnapshotTimer()
+	}
+	if ssr.config.SnapshotOnlyIfLeader {
+		isLeader, err := ssr.isEtcdLeader()
+		if err != nil {
+			// Proceed with the snapshot when the member role cannot be
+			// determined rather than silently skipping backups.
+			ssr.logger.Warnf("Failed to determine whether the local etcd member is the leader, proceeding with full snapshot: %v", err)
+		} else if !isLeader {
+			ssr.logger.Info("Local etcd member is not the leader, skipping scheduled full snapshot.")
+			return nil, ssr.resetFullSnapshotTimer()
+		}
+	}
 	ssr.logger.Infof("Taking scheduled full snapshot for time: %s", time.Now().Local())
 	s, err := ssr.takeFullSnapshot(isFinal)
 	if err != nil {
 		// As per design principle, in business critical service if backup is not working,
 		// it's better to fail the process. So, we are quiting here.
 		ssr.logger.Warnf("Taking scheduled full snapshot failed: %v", err)
+		ssr.breaker.recordFailure()
+		ssr.recordError(brtypes.SnapshotKindFull, err)
 		return nil, err
 	}
+	ssr.breaker.recordSuccess()
 
 	return s, ssr.resetFullSnapshotTimer()
 }
 
+// SetDeltaSnapshotMemoryLimit updates the delta snapshot memory limit at
+// runtime. The new limit takes effect on the next delta accumulation cycle.
+func (ssr *Snapshotter) SetDeltaSnapshotMemoryLimit(limit uint) error {
+	if limit < 1 {
+		return fmt.Errorf("delta snapshot memory limit should be greater than zero")
+	}
+	ssr.configMutex.Lock()
+	defer ssr.configMutex.Unlock()
+	ssr.config.DeltaSnapshotMemoryLimit = limit
+	return nil
+}
+
+// GetDeltaSnapshotMemoryLimit returns the currently effective delta snapshot memory limit.
+func (ssr *Snapshotter) GetDeltaSnapshotMemoryLimit() uint {
+	ssr.configMutex.Lock()
+	defer ssr.configMutex.Unlock()
+	return ssr.config.DeltaSnapshotMemoryLimit
+}
+
+// IsBreakerOpen reports whether the snapshot circuit breaker is currently
+// open, i.e. snapshot attempts are being skipped after repeated failures.
+func (ssr *Snapshotter) IsBreakerOpen() bool {
+	return ssr.breaker.isOpen()
+}
+
+// isEtcdLeader reports whether the local etcd member is currently the cluster leader.
+func (ssr *Snapshotter) isEtcdLeader() (bool, error) {
+	clientFactory := etcdutil.NewClientFactory(ssr.NewClientFactory, *ssr.etcdConnectionConfig)
+	clientMaintenance, err := clientFactory.NewMaintenance()
+	if err != nil {
+		return false, &errors.EtcdError{
+			Message: fmt.Sprintf("failed to create etcd maintenance client: %v", err),
+		}
+	}
+	defer clientMaintenance.Close()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), ssr.etcdConnectionConfig.ConnectionTimeout.Duration)
+	defer cancel()
+	return etcdutil.IsMemberLeader(ctx, clientMaintenance, ssr.etcdConnectionConfig.Endpoints[0])
+}
+
 // takeFullSnapshot will store full snapshot of etcd to brtypes.
 // It basically will connect to etcd. Then ask for snapshot. And finally
 // store it to underlying snapstore on the fly.
 func (ssr *Snapshotter) takeFullSnapshot(isFinal bool) (*brtypes.Snapshot, error) {
+	ssr.enterPhase(PhaseFullSnapshot)
+	defer ssr.enterPhase(PhaseIdle)
 	defer ssr.cleanupInMemoryEvents()
 	// close previous watch and client.
 	ssr.closeEtcdClient()
 
-	var err error
-
 	// Update the snapstore object before taking every full snapshot
 	// Refer: https://github.com/gardener/etcd-backup-restore/issues/422
-	ssr.store, err = snapstore.GetSnapstore(ssr.snapstoreConfig)
-	if err != nil {
+	if err := ssr.refreshSnapstore(); err != nil {
 		return nil, fmt.Errorf("failed to create snapstore from configured storage provider: %v", err)
 	}
 
-	clientFactory := etcdutil.NewFactory(*ssr.etcdConnectionConfig)
+	clientFactory := etcdutil.NewClientFactory(ssr.NewClientFactory, *ssr.etcdConnectionConfig)
 	clientKV, err := clientFactory.NewKV()
 	if err != nil {
 		return nil, &errors.EtcdError{
@@ -305,7 +421,7 @@ func (ssr *Snapshotter) takeFullSnapshot(isFinal bool) (*brtypes.Snapshot, error
 	}
 	defer clientKV.Close()
 
-	ctx, cancel := context.WithTimeout(context.TODO(), ssr.etcdConnectionConfig.ConnectionTimeout.Duration)
+	ctx, cancel := context.WithTimeout(context.TODO(), ssr.etcdConnectionConfig.GetRequestTimeout())
 	// Note: Although Get and snapshot call are not atomic, so revision number in snapshot file
 	// may be ahead of the revision found from GET call. But currently this is the only workaround available
 	// Refer: https://github.com/coreos/etcd/issues/9037
@@ -331,26 +447,52 @@ func (ssr *Snapshotter) takeFullSnapshot(isFinal bool) (*brtypes.Snapshot, error
 			return nil, fmt.Errorf("failed to get compressionSuffix: %v", err)
 		}
 
-		clientMaintenance, err := clientFactory.NewMaintenance()
-		if err != nil {
-			return nil, fmt.Errorf("failed to build etcd maintenance client")
+		if err := ssr.runSnapshotHook(ssr.config.PreSnapshotHook, snapshotHookData{Phase: snapshotHookPhasePre, LastRevision: lastRevision}); err != nil {
+			return nil, err
 		}
-		defer clientMaintenance.Close()
 
-		s, err := etcdutil.TakeAndSaveFullSnapshot(ctx, clientMaintenance, ssr.store, lastRevision, ssr.compressionConfig, compressionSuffix, isFinal, ssr.logger)
+		var s *brtypes.Snapshot
+		if ssr.config.KeyPrefixFilter != "" {
+			// The native snapshot API cannot be scoped to a key range, so the
+			// filtered full snapshot is taken as a key-range export instead.
+			s, err = etcdutil.TakeAndSaveKeyRangeSnapshot(ctx, clientKV, ssr.store, ssr.config.KeyPrefixFilters(), lastRevision, ssr.compressionConfig, compressionSuffix, ssr.snapstoreConfig.ObjectLayout, isFinal, ssr.logger)
+		} else {
+			// Route the snapshot request to a member matching the configured endpoint selection.
+			snapshotConnectionConfig := *ssr.etcdConnectionConfig
+			snapshotConnectionConfig.Endpoints = etcdutil.GetSnapshotEndpoints(ctx, ssr.etcdConnectionConfig, ssr.logger)
+			clientMaintenance, merr := etcdutil.NewClientFactory(ssr.NewClientFactory, snapshotConnectionConfig).NewMaintenance()
+			if merr != nil {
+				return nil, fmt.Errorf("failed to build etcd maintenance client")
+			}
+			defer clientMaintenance.Close()
+
+			s, err = etcdutil.TakeAndSaveFullSnapshot(ctx, clientMaintenance, ssr.store, lastRevision, ssr.compressionConfig, compressionSuffix, ssr.snapstoreConfig.ObjectLayout, isFinal, ssr.logger)
+		}
+
+		postHookData := snapshotHookData{Phase: snapshotHookPhasePost, LastRevision: lastRevision, Succeeded: err == nil}
+		if s != nil {
+			postHookData.SnapshotName = s.SnapName
+		}
+		if hookErr := ssr.runSnapshotHook(ssr.config.PostSnapshotHook, postHookData); hookErr != nil && err == nil {
+			err = hookErr
+		}
 		if err != nil {
 			return nil, err
 		}
 
 		ssr.prevSnapshot = s
+		ssr.prevSnapshotsMutex.Lock()
 		ssr.PrevFullSnapshot = s
 		ssr.PrevDeltaSnapshots = nil
+		ssr.prevSnapshotsMutex.Unlock()
 
 		metrics.LatestSnapshotRevision.With(prometheus.Labels{metrics.LabelKind: ssr.prevSnapshot.Kind}).Set(float64(ssr.prevSnapshot.LastRevision))
 		metrics.LatestSnapshotTimestamp.With(prometheus.Labels{metrics.LabelKind: ssr.prevSnapshot.Kind}).Set(float64(ssr.prevSnapshot.CreatedOn.Unix()))
 		metrics.SnapstoreLatestDeltasTotal.With(prometheus.Labels{}).Set(0)
 		metrics.SnapstoreLatestDeltasRevisionsTotal.With(prometheus.Labels{}).Set(0)
+		metrics.SnapshotterDeltaChainLimitExceeded.With(prometheus.Labels{}).Set(0)
 
+		ssr.recordSnapshot(brtypes.SnapshotKindFull)
 		ssr.logger.Infof("Successfully saved full snapshot at: %s", path.Join(s.SnapDir, s.SnapName))
 	}
 	// setting `snapshotRequired` to 0 for both full and delta snapshot
@@ -374,7 +516,7 @@ func (ssr *Snapshotter) takeFullSnapshot(isFinal bool) (*brtypes.Snapshot, error
 	watchCtx, cancelWatch := context.WithCancel(context.TODO())
 	ssr.cancelWatch = cancelWatch
 	ssr.etcdWatchClient = &ssrEtcdWatchClient
-	ssr.watchCh = ssrEtcdWatchClient.Watch(watchCtx, "", clientv3.WithPrefix(), clientv3.WithRev(ssr.prevSnapshot.LastRevision+1))
+	ssr.watchCh = ssrEtcdWatchClient.Watch(watchCtx, ssr.watchKeyPrefix(), clientv3.WithPrefix(), clientv3.WithRev(ssr.prevSnapshot.LastRevision+1))
 	ssr.logger.Infof("Applied watch on etcd from revision: %d", ssr.prevSnapshot.LastRevision+1)
 
 	return ssr.prevSnapshot, nil
@@ -383,17 +525,79 @@ func (ssr *Snapshotter) takeFullSnapshot(isFinal bool) (*brtypes.Snapshot, error
 func (ssr *Snapshotter) cleanupInMemoryEvents() {
 	ssr.events = []byte{}
 	ssr.lastEventRevision = -1
+	ssr.eventCount = 0
 }
 
 func (ssr *Snapshotter) takeDeltaSnapshotAndResetTimer() (*brtypes.Snapshot, error) {
+	if !ssr.breaker.allowAttempt() {
+		ssr.logger.Warnf("Circuit breaker is open, skipping delta snapshot.")
+		ssr.resetDeltaSnapshotTimer()
+		return nil, nil
+	}
 	s, err := ssr.TakeDeltaSnapshot()
 	if err != nil {
 		// As per design principle, in business critical service if backup is not working,
 		// it's better to fail the process. So, we are quiting here.
 		ssr.logger.Warnf("Taking delta snapshot failed: %v", err)
+		ssr.breaker.recordFailure()
+		ssr.recordError(brtypes.SnapshotKindDelta, err)
 		return nil, err
 	}
+	ssr.breaker.recordSuccess()
+	if s != nil {
+		ssr.checkDeltaSnapshotChainLimit()
+	}
+
+	ssr.resetDeltaSnapshotTimer()
+	return s, nil
+}
 
+// checkDeltaSnapshotChainLimit checks whether the delta snapshot chain since
+// the latest full snapshot has grown beyond the configured limit, so that the
+// time needed to restore from the chain stays bounded. Depending on the
+// configured action it either takes an out-of-schedule full snapshot to cut
+// the chain or raises a warning log and metric.
+func (ssr *Snapshotter) checkDeltaSnapshotChainLimit() {
+	if ssr.config.DeltaSnapshotChainLimit == 0 || uint(len(ssr.PrevDeltaSnapshots)) <= ssr.config.DeltaSnapshotChainLimit {
+		return
+	}
+	if ssr.config.DeltaSnapshotChainLimitAction == brtypes.DeltaSnapshotChainLimitActionSnapshot {
+		ssr.logger.Infof("Delta snapshot chain has grown to %d snapshots, exceeding the limit of %d. Taking an out-of-schedule full snapshot.", len(ssr.PrevDeltaSnapshots), ssr.config.DeltaSnapshotChainLimit)
+		if _, err := ssr.TakeFullSnapshotAndResetTimer(false); err != nil {
+			ssr.logger.Warnf("Taking out-of-schedule full snapshot failed: %v", err)
+		}
+		return
+	}
+	ssr.logger.Warnf("Delta snapshot chain has grown to %d snapshots, exceeding the limit of %d. Restoration from this chain may take long.", len(ssr.PrevDeltaSnapshots), ssr.config.DeltaSnapshotChainLimit)
+	metrics.SnapshotterDeltaChainLimitExceeded.With(prometheus.Labels{}).Set(1)
+}
+
+// takeFinalDeltaSnapshot takes one last delta snapshot of the events
+// accumulated since the previous snapshot, so that a graceful shutdown does
+// not lose the most recent etcd writes from the backup. It is bounded by the
+// final delta snapshot grace period: a snapshot that does not complete in
+// time is left to finish in the background rather than hang the shutdown.
+func (ssr *Snapshotter) takeFinalDeltaSnapshot() {
+	if len(ssr.events) == 0 {
+		ssr.cleanupInMemoryEvents()
+		return
+	}
+	ssr.logger.Info("Taking final delta snapshot before shutdown.")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := ssr.TakeDeltaSnapshot(); err != nil {
+			ssr.logger.Warnf("Taking final delta snapshot failed: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(finalDeltaSnapshotGracePeriod):
+		ssr.logger.Warnf("Final delta snapshot did not complete within %s. Proceeding with shutdown.", finalDeltaSnapshotGracePeriod)
+	}
+}
+
+func (ssr *Snapshotter) resetDeltaSnapshotTimer() {
 	if ssr.deltaSnapshotTimer == nil {
 		ssr.deltaSnapshotTimer = time.NewTimer(ssr.config.DeltaSnapshotPeriod.Duration)
 	} else {
@@ -402,12 +606,13 @@ func (ssr *Snapshotter) takeDeltaSnapshotAndResetTimer() (*brtypes.Snapshot, err
 		ssr.logger.Infof("Resetting delta snapshot to run after %s.", ssr.config.DeltaSnapshotPeriod.Duration.String())
 		ssr.deltaSnapshotTimer.Reset(ssr.config.DeltaSnapshotPeriod.Duration)
 	}
-	return s, nil
 }
 
 // TakeDeltaSnapshot takes a delta snapshot that contains
 // the etcd events collected up till now
 func (ssr *Snapshotter) TakeDeltaSnapshot() (*brtypes.Snapshot, error) {
+	ssr.enterPhase(PhaseDeltaSnapshot)
+	defer ssr.enterPhase(PhaseIdle)
 	defer ssr.cleanupInMemoryEvents()
 	ssr.logger.Infof("Taking delta snapshot for time: %s", time.Now().Local())
 
@@ -416,16 +621,13 @@ func (ssr *Snapshotter) TakeDeltaSnapshot() (*brtypes.Snapshot, error) {
 		metrics.SnapshotRequired.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta}).Set(0)
 		return nil, nil
 	}
-	ssr.events = append(ssr.events, byte(']'))
+	ssr.events = ssr.deltaCodec.Finalize(ssr.events)
 
 	isSecretUpdated := ssr.checkSnapstoreSecretUpdate()
 	if isSecretUpdated {
-		var err error
-
 		// Update the snapstore object before taking every delta snapshot
 		// Refer: https://github.com/gardener/etcd-backup-restore/issues/422
-		ssr.store, err = snapstore.GetSnapstore(ssr.snapstoreConfig)
-		if err != nil {
+		if err := ssr.refreshSnapstore(); err != nil {
 			return nil, fmt.Errorf("failed to create snapstore from configured storage provider: %v", err)
 		}
 		ssr.logger.Info("updated the snapstore object with new credentials")
@@ -438,6 +640,9 @@ func (ssr *Snapshotter) TakeDeltaSnapshot() (*brtypes.Snapshot, error) {
 		return nil, fmt.Errorf("failed to get compressionSuffix: %v", err)
 	}
 	snap := snapstore.NewSnapshot(brtypes.SnapshotKindDelta, ssr.prevSnapshot.LastRevision+1, ssr.lastEventRevision, compressionSuffix, false)
+	if ssr.snapstoreConfig.ObjectLayout == brtypes.ObjectLayoutDate {
+		snap.GenerateDateDirectory()
+	}
 
 	// compute hash
 	hash := sha256.New()
@@ -470,7 +675,9 @@ func (ssr *Snapshotter) TakeDeltaSnapshot() (*brtypes.Snapshot, error) {
 	metrics.SnapshotDurationSeconds.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Observe(timeTaken)
 	logrus.Infof("Total time to save delta snapshot: %f seconds.", timeTaken)
 	ssr.prevSnapshot = snap
+	ssr.prevSnapshotsMutex.Lock()
 	ssr.PrevDeltaSnapshots = append(ssr.PrevDeltaSnapshots, snap)
+	ssr.prevSnapshotsMutex.Unlock()
 
 	metrics.LatestSnapshotRevision.With(prometheus.Labels{metrics.LabelKind: ssr.prevSnapshot.Kind}).Set(float64(ssr.prevSnapshot.LastRevision))
 	metrics.LatestSnapshotTimestamp.With(prometheus.Labels{metrics.LabelKind: ssr.prevSnapshot.Kind}).Set(float64(ssr.prevSnapshot.CreatedOn.Unix()))
@@ -478,16 +685,28 @@ func (ssr *Snapshotter) TakeDeltaSnapshot() (*brtypes.Snapshot, error) {
 	metrics.SnapstoreLatestDeltasTotal.With(prometheus.Labels{}).Inc()
 	metrics.SnapstoreLatestDeltasRevisionsTotal.With(prometheus.Labels{}).Add(float64(snap.LastRevision - snap.StartRevision))
 
+	ssr.recordSnapshot(brtypes.SnapshotKindDelta)
 	ssr.logger.Infof("Successfully saved delta snapshot at: %s", path.Join(snap.SnapDir, snap.SnapName))
 	return snap, nil
 }
 
+// GetLatestSnapshotMetadata returns the latest full snapshot along with the
+// delta snapshots taken on top of it. It is safe to call concurrently with the
+// snapshot loop, which updates this metadata after every snapshot.
+func (ssr *Snapshotter) GetLatestSnapshotMetadata() (*brtypes.Snapshot, brtypes.SnapList) {
+	ssr.prevSnapshotsMutex.RLock()
+	defer ssr.prevSnapshotsMutex.RUnlock()
+	deltaSnapshots := make(brtypes.SnapList, len(ssr.PrevDeltaSnapshots))
+	copy(deltaSnapshots, ssr.PrevDeltaSnapshots)
+	return ssr.PrevFullSnapshot, deltaSnapshots
+}
+
 // CollectEventsSincePrevSnapshot takes the first delta snapshot on etcd startup.
 func (ssr *Snapshotter) CollectEventsSincePrevSnapshot(stopCh <-chan struct{}) (bool, error) {
 	// close any previous watch and client.
 	ssr.closeEtcdClient()
 
-	clientFactory := etcdutil.NewFactory(*ssr.etcdConnectionConfig)
+	clientFactory := etcdutil.NewClientFactory(ssr.NewClientFactory, *ssr.etcdConnectionConfig)
 	clientKV, err := clientFactory.NewKV()
 	if err != nil {
 		return false, &errors.EtcdError{
@@ -496,7 +715,7 @@ func (ssr *Snapshotter) CollectEventsSincePrevSnapshot(stopCh <-chan struct{}) (
 	}
 	defer clientKV.Close()
 
-	ctx, cancel := context.WithTimeout(context.TODO(), ssr.etcdConnectionConfig.ConnectionTimeout.Duration)
+	ctx, cancel := context.WithTimeout(context.TODO(), ssr.etcdConnectionConfig.GetRequestTimeout())
 	resp, err := clientKV.Get(ctx, "", clientv3.WithLastRev()...)
 	cancel()
 	if err != nil {
@@ -525,7 +744,7 @@ func (ssr *Snapshotter) CollectEventsSincePrevSnapshot(stopCh <-chan struct{}) (
 	watchCtx, cancelWatch := context.WithCancel(context.TODO())
 	ssr.cancelWatch = cancelWatch
 	ssr.etcdWatchClient = &ssrEtcdWatchClient
-	ssr.watchCh = ssrEtcdWatchClient.Watch(watchCtx, "", clientv3.WithPrefix(), clientv3.WithRev(ssr.prevSnapshot.LastRevision+1))
+	ssr.watchCh = ssrEtcdWatchClient.Watch(watchCtx, ssr.watchKeyPrefix(), clientv3.WithPrefix(), clientv3.WithRev(ssr.prevSnapshot.LastRevision+1))
 	ssr.logger.Infof("Applied watch on etcd from revision: %d", ssr.prevSnapshot.LastRevision+1)
 
 	if ssr.prevSnapshot.LastRevision == lastEtcdRevision {
@@ -560,38 +779,67 @@ func (ssr *Snapshotter) CollectEventsSincePrevSnapshot(stopCh <-chan struct{}) (
 	}
 }
 
+// watchKeyPrefix returns the key the delta watch is scoped to. A single
+// configured prefix is enforced server-side; with multiple prefixes the whole
+// keyspace is watched and events outside the prefixes are dropped before they
+// are recorded.
+func (ssr *Snapshotter) watchKeyPrefix() string {
+	if prefixes := ssr.config.KeyPrefixFilters(); len(prefixes) == 1 {
+		return prefixes[0]
+	}
+	return ""
+}
+
+// matchesKeyPrefixFilter reports whether the event's key falls under one of the
+// configured key prefix filters. All events match when no filter is configured.
+func (ssr *Snapshotter) matchesKeyPrefixFilter(ev *clientv3.Event) bool {
+	prefixes := ssr.config.KeyPrefixFilters()
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(ev.Kv.Key, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (ssr *Snapshotter) handleDeltaWatchEvents(wr clientv3.WatchResponse) error {
 	if err := wr.Err(); err != nil {
 		return err
 	}
 	// aggregate events
 	for _, ev := range wr.Events {
-		timedEvent := newEvent(ev)
-		jsonByte, err := json.Marshal(timedEvent)
-		if err != nil {
-			return fmt.Errorf("failed to marshal events to json: %v", err)
+		if !ssr.matchesKeyPrefixFilter(ev) {
+			continue
 		}
-		if len(ssr.events) == 0 {
-			ssr.events = append(ssr.events, byte('['))
-		} else {
-			ssr.events = append(ssr.events, byte(','))
+		events, err := ssr.deltaCodec.AppendEvent(ssr.events, newEvent(ev))
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %v", err)
 		}
-		ssr.events = append(ssr.events, jsonByte...)
+		ssr.events = events
 		ssr.lastEventRevision = ev.Kv.ModRevision
+		ssr.eventCount++
 		metrics.SnapshotRequired.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindFull}).Set(1)
 		metrics.SnapshotRequired.With(prometheus.Labels{metrics.LabelKind: brtypes.SnapshotKindDelta}).Set(1)
 	}
 	ssr.logger.Debugf("Added events till revision: %d", ssr.lastEventRevision)
-	if len(ssr.events) >= int(ssr.config.DeltaSnapshotMemoryLimit) {
+	if len(ssr.events) >= int(ssr.GetDeltaSnapshotMemoryLimit()) {
 		ssr.logger.Infof("Delta events memory crossed the memory limit: %d Bytes", len(ssr.events))
 		_, err := ssr.takeDeltaSnapshotAndResetTimer()
 		return err
 	}
+	if ssr.config.DeltaSnapshotEventThreshold > 0 && ssr.eventCount >= ssr.config.DeltaSnapshotEventThreshold {
+		ssr.logger.Infof("Collected delta events count %d crossed the event threshold: %d", ssr.eventCount, ssr.config.DeltaSnapshotEventThreshold)
+		_, err := ssr.takeDeltaSnapshotAndResetTimer()
+		return err
+	}
 	return nil
 }
 
-func newEvent(e *clientv3.Event) *event {
-	return &event{
+func newEvent(e *clientv3.Event) *brtypes.Event {
+	return &brtypes.Event{
 		EtcdEvent: e,
 		Time:      time.Now(),
 	}
@@ -602,6 +850,7 @@ func (ssr *Snapshotter) snapshotEventHandler(stopCh <-chan struct{}) error {
 	defer leaseUpdateCancel()
 	ssr.logger.Info("Starting the Snapshot EventHandler.")
 	for {
+		ssr.recordLoopActivity()
 		select {
 		case isFinal := <-ssr.fullSnapshotReqCh:
 			s, err := ssr.TakeFullSnapshotAndResetTimer(isFinal)
@@ -687,6 +936,10 @@ func (ssr *Snapshotter) snapshotEventHandler(stopCh <-chan struct{}) error {
 		case <-stopCh:
 			ssr.logger.Info("Closing the Snapshot EventHandler.")
 			leaseUpdateCancel()
+			if ssr.config.FinalDeltaSnapshotOnShutdown {
+				ssr.takeFinalDeltaSnapshot()
+				return nil
+			}
 			ssr.cleanupInMemoryEvents()
 			return nil
 		}
@@ -700,6 +953,9 @@ func (ssr *Snapshotter) resetFullSnapshotTimer() error {
 		ssr.logger.Info("There are no backups scheduled for the future. Stopping now.")
 		return fmt.Errorf("error in full snapshot schedule")
 	}
+	// Delaying every run by the same offset spreads replicas sharing a schedule
+	// without skipping or double-firing a period.
+	effective = effective.Add(ssr.scheduleJitterOffset)
 	duration := effective.Sub(now)
 	if ssr.fullSnapshotTimer == nil {
 		ssr.fullSnapshotTimer = time.NewTimer(duration)
@@ -709,11 +965,52 @@ func (ssr *Snapshotter) resetFullSnapshotTimer() error {
 		ssr.logger.Infof("Resetting full snapshot to run after %s", duration)
 		ssr.fullSnapshotTimer.Reset(duration)
 	}
+	ssr.recordNextFullSnapshotTime(effective)
 	ssr.logger.Infof("Will take next full snapshot at time: %s", effective)
 
 	return nil
 }
 
+// AddReplicaSnapStores wraps the snapshotter's snapstore so that every snapshot
+// is replicated to the given replica snapstores after a successful save to the
+// primary store, and garbage collection deletions are propagated to them.
+// Replication failures fail the snapshot only when failOnReplicationError is
+// set. Must be called before Run.
+func (ssr *Snapshotter) AddReplicaSnapStores(failOnReplicationError bool, replicas ...brtypes.SnapStore) {
+	if len(replicas) == 0 {
+		return
+	}
+	ssr.store = snapstore.NewReplicatedSnapStore(ssr.store, failOnReplicationError, replicas...)
+}
+
+// refreshSnapstore recreates the snapstore object from the configured storage
+// provider so that updated credentials are picked up, while retaining the
+// snapshot list cache across the refresh.
+func (ssr *Snapshotter) refreshSnapstore() error {
+	store, err := snapstore.GetSnapstore(ssr.snapstoreConfig)
+	if err != nil {
+		return err
+	}
+	if ssr.config.UploadBytesPerSecond > 0 {
+		store = snapstore.NewThrottledSnapStore(store, ssr.config.UploadBytesPerSecond, 0)
+	}
+	// Walk the decorator chain so that outer wrappers such as a replicating
+	// snapstore stay in place across the refresh.
+	for s := ssr.store; ; {
+		if cachingStore, ok := s.(*snapstore.CachingSnapStore); ok {
+			cachingStore.SetBackingStore(store)
+			return nil
+		}
+		w, ok := s.(brtypes.WrappingSnapStore)
+		if !ok {
+			break
+		}
+		s = w.WrappedSnapStore()
+	}
+	ssr.store = snapstore.NewCachingSnapStore(store, snapListCacheTTL)
+	return nil
+}
+
 func (ssr *Snapshotter) checkSnapstoreSecretUpdate() bool {
 	ssr.logger.Debug("checking the hash of snapstore secret...")
 	newSnapstoreSecretHash, err := snapstore.GetSnapstoreSecretHash(ssr.snapstoreConfig)
@@ -16,8 +16,17 @@ package snapshotter_test
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path"
 	"testing"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
 
 	"github.com/gardener/etcd-backup-restore/test/utils"
 	. "github.com/onsi/ginkgo"
@@ -59,3 +68,38 @@ var _ = SynchronizedAfterSuite(func() {}, func() {
 	etcd.Server.Stop()
 	etcd.Close()
 })
+
+// newTestEtcdConnectionConfig returns an etcd connection config pointing at the
+// suite's embedded etcd.
+func newTestEtcdConnectionConfig() *brtypes.EtcdConnectionConfig {
+	etcdConnectionConfig := brtypes.NewEtcdConnectionConfig()
+	etcdConnectionConfig.Endpoints = []string{etcd.Clients[0].Addr().String()}
+	etcdConnectionConfig.ConnectionTimeout.Duration = 5 * time.Second
+	return etcdConnectionConfig
+}
+
+// newTestSnapshotterConfig returns the snapshotter config fixture shared by the
+// snapshotter specs. Specs adjust the returned config as needed.
+func newTestSnapshotterConfig() *brtypes.SnapshotterConfig {
+	currentHour := time.Now().Hour()
+	return &brtypes.SnapshotterConfig{
+		FullSnapshotSchedule:     fmt.Sprintf("59 %d * * *", (currentHour+1)%24), // This make sure that full snapshot timer doesn't trigger full snapshot.
+		DeltaSnapshotPeriod:      wrappers.Duration{Duration: 10 * time.Second},
+		DeltaSnapshotMemoryLimit: brtypes.DefaultDeltaSnapMemoryLimit,
+		GarbageCollectionPeriod:  wrappers.Duration{Duration: 30 * time.Second},
+		GarbageCollectionPolicy:  brtypes.GarbageCollectionPolicyExponential,
+		MaxBackups:               1,
+	}
+}
+
+// newTestSnapshotter creates a snapshotter with default compression and health
+// configs over a fresh local snapstore in the given container under the
+// suite's output directory.
+func newTestSnapshotter(snapshotterConfig *brtypes.SnapshotterConfig, etcdConnectionConfig *brtypes.EtcdConnectionConfig, container string) (*Snapshotter, brtypes.SnapStore, *brtypes.SnapstoreConfig) {
+	snapstoreConfig := &brtypes.SnapstoreConfig{Container: path.Join(outputDir, container)}
+	store, err := snapstore.GetSnapstore(snapstoreConfig)
+	Expect(err).ShouldNot(HaveOccurred())
+	ssr, err := NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressor.NewCompressorConfig(), brtypes.NewHealthConfig(), snapstoreConfig)
+	Expect(err).ShouldNot(HaveOccurred())
+	return ssr, store, snapstoreConfig
+}
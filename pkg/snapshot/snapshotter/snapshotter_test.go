@@ -298,6 +298,83 @@ var _ = Describe("Snapshotter", func() {
 						})
 					})
 
+					Context("with delta snapshot event threshold set", func() {
+						It("should take an early delta snapshot once events cross the threshold", func() {
+							currentHour := time.Now().Hour()
+							snapstoreConfig = &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_7.bkp")}
+							store, err = snapstore.GetSnapstore(snapstoreConfig)
+							Expect(err).ShouldNot(HaveOccurred())
+							snapshotterConfig := &brtypes.SnapshotterConfig{
+								FullSnapshotSchedule:        fmt.Sprintf("59 %d * * *", (currentHour+1)%24), // This make sure that full snapshot timer doesn't trigger full snapshot.
+								DeltaSnapshotPeriod:         wrappers.Duration{Duration: time.Minute},       // won't elapse within the test timeout
+								DeltaSnapshotMemoryLimit:    brtypes.DefaultDeltaSnapMemoryLimit,
+								DeltaSnapshotEventThreshold: 5,
+								GarbageCollectionPeriod:     wrappers.Duration{Duration: garbageCollectionPeriod},
+								GarbageCollectionPolicy:     brtypes.GarbageCollectionPolicyExponential,
+								MaxBackups:                  maxBackups,
+							}
+
+							ssr, err = NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, healthConfig, snapstoreConfig)
+							Expect(err).ShouldNot(HaveOccurred())
+
+							ctx, cancel := context.WithTimeout(testCtx, 30*time.Second)
+							defer cancel()
+							// drive more events than the threshold to trigger an early delta snapshot
+							go utils.PopulateEtcd(ctx, logger, etcdConnectionConfig.Endpoints, 0, 25, nil)
+							err = ssr.Run(ctx.Done(), true)
+							Expect(err).ShouldNot(HaveOccurred())
+							list, err := store.List()
+							Expect(err).ShouldNot(HaveOccurred())
+							deltaCount := 0
+							for _, snap := range list {
+								if snap.Kind == brtypes.SnapshotKindDelta {
+									deltaCount++
+								}
+							}
+							Expect(deltaCount).Should(BeNumerically(">=", 1))
+						})
+					})
+
+					Context("with delta snapshot memory limit lowered at runtime", func() {
+						It("should take an early delta snapshot once events cross the lowered limit", func() {
+							currentHour := time.Now().Hour()
+							snapstoreConfig = &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_14.bkp")}
+							store, err = snapstore.GetSnapstore(snapstoreConfig)
+							Expect(err).ShouldNot(HaveOccurred())
+							snapshotterConfig := &brtypes.SnapshotterConfig{
+								FullSnapshotSchedule:     fmt.Sprintf("59 %d * * *", (currentHour+1)%24), // This make sure that full snapshot timer doesn't trigger full snapshot.
+								DeltaSnapshotPeriod:      wrappers.Duration{Duration: time.Minute},       // won't elapse within the test timeout
+								DeltaSnapshotMemoryLimit: brtypes.DefaultDeltaSnapMemoryLimit,
+								GarbageCollectionPeriod:  wrappers.Duration{Duration: garbageCollectionPeriod},
+								GarbageCollectionPolicy:  brtypes.GarbageCollectionPolicyExponential,
+								MaxBackups:               maxBackups,
+							}
+
+							ssr, err = NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, healthConfig, snapstoreConfig)
+							Expect(err).ShouldNot(HaveOccurred())
+
+							Expect(ssr.SetDeltaSnapshotMemoryLimit(0)).Should(HaveOccurred())
+							// lower the limit so that the next accumulated event already crosses it
+							Expect(ssr.SetDeltaSnapshotMemoryLimit(1)).ShouldNot(HaveOccurred())
+							Expect(ssr.GetDeltaSnapshotMemoryLimit()).Should(Equal(uint(1)))
+
+							ctx, cancel := context.WithTimeout(testCtx, 30*time.Second)
+							defer cancel()
+							go utils.PopulateEtcd(ctx, logger, etcdConnectionConfig.Endpoints, 0, 10, nil)
+							err = ssr.Run(ctx.Done(), true)
+							Expect(err).ShouldNot(HaveOccurred())
+							list, err := store.List()
+							Expect(err).ShouldNot(HaveOccurred())
+							deltaCount := 0
+							for _, snap := range list {
+								if snap.Kind == brtypes.SnapshotKindDelta {
+									deltaCount++
+								}
+							}
+							Expect(deltaCount).Should(BeNumerically(">=", 1))
+						})
+					})
+
 					Context("with snapshotter starting with full snapshot", func() {
 						It("should take periodic backups", func() {
 							snapstoreConfig = &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_6.bkp")}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+)
+
+// Phases of the snapshotter state machine exposed via the /snapshot/state HTTP endpoint.
+const (
+	// PhaseIdle indicates the snapshotter is waiting for the next scheduled operation.
+	PhaseIdle = "Idle"
+	// PhaseFullSnapshot indicates the snapshotter is taking a full snapshot.
+	PhaseFullSnapshot = "TakingFullSnapshot"
+	// PhaseDeltaSnapshot indicates the snapshotter is taking a delta snapshot.
+	PhaseDeltaSnapshot = "TakingDeltaSnapshot"
+	// PhaseGarbageCollection indicates the snapshotter is garbage collecting old snapshots.
+	PhaseGarbageCollection = "RunningGarbageCollection"
+	// PhaseStopped indicates the snapshotter has been stopped.
+	PhaseStopped = "Stopped"
+)
+
+// State describes the snapshotter's current position in its state machine
+// along with statistics about the snapshots taken this session.
+type State struct {
+	// Phase is the phase the snapshotter is currently in.
+	Phase string `json:"phase"`
+	// PhaseEnteredAt is the time the snapshotter entered the current phase.
+	PhaseEnteredAt time.Time `json:"phaseEnteredAt"`
+	// NextFullSnapshotAt is the time the next full snapshot is scheduled for.
+	NextFullSnapshotAt time.Time `json:"nextFullSnapshotAt"`
+	// FullSnapshotsTaken is the number of full snapshots taken this session.
+	FullSnapshotsTaken uint `json:"fullSnapshotsTaken"`
+	// DeltaSnapshotsTaken is the number of delta snapshots taken this session.
+	DeltaSnapshotsTaken uint `json:"deltaSnapshotsTaken"`
+}
+
+// State returns a copy of the snapshotter's current state.
+func (ssr *Snapshotter) State() State {
+	ssr.stateMutex.Lock()
+	defer ssr.stateMutex.Unlock()
+	return ssr.state
+}
+
+// enterPhase records the transition of the snapshotter into the given phase.
+func (ssr *Snapshotter) enterPhase(phase string) {
+	ssr.stateMutex.Lock()
+	defer ssr.stateMutex.Unlock()
+	ssr.state.Phase = phase
+	ssr.state.PhaseEnteredAt = time.Now().UTC()
+}
+
+// recordSnapshot counts a snapshot of the given kind taken in this session.
+func (ssr *Snapshotter) recordSnapshot(kind string) {
+	ssr.stateMutex.Lock()
+	defer ssr.stateMutex.Unlock()
+	switch kind {
+	case brtypes.SnapshotKindFull:
+		ssr.state.FullSnapshotsTaken++
+	case brtypes.SnapshotKindDelta:
+		ssr.state.DeltaSnapshotsTaken++
+	}
+}
+
+// recordNextFullSnapshotTime records the time the next full snapshot is scheduled for.
+func (ssr *Snapshotter) recordNextFullSnapshotTime(next time.Time) {
+	ssr.stateMutex.Lock()
+	defer ssr.stateMutex.Unlock()
+	ssr.state.NextFullSnapshotAt = next
+}
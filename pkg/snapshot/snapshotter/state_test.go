@@ -0,0 +1,61 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"time"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	"github.com/gardener/etcd-backup-restore/test/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshotter state", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = newTestEtcdConnectionConfig()
+		snapshotterConfig = newTestSnapshotterConfig()
+		snapshotterConfig.FullSnapshotSchedule = "*/1 * * * *"
+		snapshotterConfig.DeltaSnapshotPeriod = wrappers.Duration{Duration: 10}
+		// make sure there are events to be snapshotted
+		utils.PopulateEtcd(testCtx, logger, etcdConnectionConfig.Endpoints, 0, 2, nil)
+	})
+
+	It("should report the current phase and the snapshots taken this session", func() {
+		ssr, _, _ := newTestSnapshotter(snapshotterConfig, etcdConnectionConfig, "snapshotter_12.bkp")
+
+		state := ssr.State()
+		Expect(state.Phase).Should(Equal(PhaseIdle))
+		Expect(state.FullSnapshotsTaken).Should(BeZero())
+		Expect(state.DeltaSnapshotsTaken).Should(BeZero())
+
+		_, err := ssr.TakeFullSnapshotAndResetTimer(false)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		state = ssr.State()
+		Expect(state.Phase).Should(Equal(PhaseIdle))
+		Expect(state.PhaseEnteredAt.IsZero()).Should(BeFalse())
+		Expect(state.FullSnapshotsTaken).Should(Equal(uint(1)))
+		Expect(state.NextFullSnapshotAt.After(time.Now())).Should(BeTrue())
+	})
+})
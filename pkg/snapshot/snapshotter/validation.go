@@ -0,0 +1,97 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/metrics"
+	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/restorer"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/etcd/pkg/types"
+)
+
+// ValidateBackup verifies that the latest snapshot chain in the store is
+// restorable by replaying it into a temporary directory, which is discarded
+// afterwards. The live etcd is not contacted, so validation does not interfere
+// with active snapshotting. An empty store validates trivially.
+func (ssr *Snapshotter) ValidateBackup(ctx context.Context) error {
+	fullSnap, deltaSnapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(ssr.store)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots for validation: %v", err)
+	}
+	if fullSnap == nil {
+		ssr.logger.Info("No full snapshot found in the store, skipping backup validation.")
+		return nil
+	}
+
+	restoreDir, err := os.MkdirTemp(ssr.snapstoreConfig.TempDir, "validate-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary restoration directory: %v", err)
+	}
+	defer os.RemoveAll(restoreDir)
+
+	restorationConfig := brtypes.NewRestorationConfig()
+	restorationConfig.RestoreDataDir = restoreDir
+	clusterURLsMap, err := types.NewURLsMap(restorationConfig.InitialCluster)
+	if err != nil {
+		return fmt.Errorf("failed creating url map for restore cluster: %v", err)
+	}
+	peerURLs, err := types.NewURLs(restorationConfig.InitialAdvertisePeerURLs)
+	if err != nil {
+		return fmt.Errorf("failed parsing peers urls for restore cluster: %v", err)
+	}
+
+	ssr.logger.Infof("Validating restorability of full snapshot %s and %d delta snapshot(s)...", fullSnap.SnapName, len(deltaSnapList))
+	rs := restorer.NewRestorer(ssr.store, ssr.logger)
+	if err := rs.RestoreAndStopEtcd(brtypes.RestoreOptions{
+		Config:        restorationConfig,
+		BaseSnapshot:  fullSnap,
+		DeltaSnapList: deltaSnapList,
+		ClusterURLs:   clusterURLsMap,
+		PeerURLs:      peerURLs,
+	}, nil); err != nil {
+		return fmt.Errorf("failed to restore the snapshot chain: %v", err)
+	}
+	return nil
+}
+
+// runBackupValidation periodically validates the latest snapshot chain until
+// the given context is cancelled, reporting the outcome via log and metric.
+func (ssr *Snapshotter) runBackupValidation(ctx context.Context) {
+	ticker := time.NewTicker(ssr.config.BackupValidationPeriod.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := ssr.ValidateBackup(ctx); err != nil {
+				metrics.BackupRestorable.With(prometheus.Labels{}).Set(0)
+				ssr.logger.Warnf("Backup validation failed: %v", err)
+				continue
+			}
+			metrics.BackupRestorable.With(prometheus.Labels{}).Set(1)
+			ssr.logger.Infof("Backup validation succeeded in %v.", time.Since(start))
+		}
+	}
+}
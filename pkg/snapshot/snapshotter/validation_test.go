@@ -0,0 +1,91 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter_test
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/compressor"
+	"github.com/gardener/etcd-backup-restore/pkg/miscellaneous"
+	. "github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
+
+	"github.com/gardener/etcd-backup-restore/test/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshotter backup validation", func() {
+	var (
+		etcdConnectionConfig *brtypes.EtcdConnectionConfig
+		compressionConfig    *compressor.CompressionConfig
+		healthConfig         *brtypes.HealthConfig
+		snapshotterConfig    *brtypes.SnapshotterConfig
+	)
+
+	BeforeEach(func() {
+		etcdConnectionConfig = brtypes.NewEtcdConnectionConfig()
+		etcdConnectionConfig.Endpoints = []string{etcd.Clients[0].Addr().String()}
+		etcdConnectionConfig.ConnectionTimeout.Duration = 10 * time.Second
+		compressionConfig = compressor.NewCompressorConfig()
+		healthConfig = brtypes.NewHealthConfig()
+		currentHour := time.Now().Hour()
+		snapshotterConfig = &brtypes.SnapshotterConfig{
+			FullSnapshotSchedule:     fmt.Sprintf("59 %d * * *", (currentHour+1)%24), // This make sure that full snapshot timer doesn't trigger full snapshot.
+			DeltaSnapshotPeriod:      wrappers.Duration{Duration: 5 * time.Second},
+			DeltaSnapshotMemoryLimit: brtypes.DefaultDeltaSnapMemoryLimit,
+			GarbageCollectionPeriod:  wrappers.Duration{Duration: time.Minute},
+			GarbageCollectionPolicy:  brtypes.GarbageCollectionPolicyExponential,
+			MaxBackups:               brtypes.DefaultMaxBackups,
+		}
+	})
+
+	It("should validate the latest snapshot chain by restoring it into a temporary directory", func() {
+		snapstoreConfig := &brtypes.SnapstoreConfig{Container: path.Join(outputDir, "snapshotter_18.bkp")}
+		store, err := snapstore.GetSnapstore(snapstoreConfig)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		ssr, err := NewSnapshotter(logger, snapshotterConfig, store, etcdConnectionConfig, compressionConfig, healthConfig, snapstoreConfig)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// an empty store validates trivially
+		Expect(ssr.ValidateBackup(testCtx)).ShouldNot(HaveOccurred())
+
+		// build a chain of one full snapshot plus periodic delta snapshots
+		populatorCtx, cancelPopulator := context.WithTimeout(testCtx, 15*time.Second)
+		defer cancelPopulator()
+		resp := &utils.EtcdDataPopulationResponse{}
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go utils.PopulateEtcdWithWaitGroup(populatorCtx, wg, logger, etcdConnectionConfig.Endpoints, resp)
+		ssrCtx := utils.ContextWithWaitGroupFollwedByGracePeriod(populatorCtx, wg, 10*time.Second)
+		err = ssr.Run(ssrCtx.Done(), true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Err).ShouldNot(HaveOccurred())
+
+		fullSnap, deltaSnapList, err := miscellaneous.GetLatestFullSnapshotAndDeltaSnapList(store)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(fullSnap).ShouldNot(BeNil())
+		Expect(len(deltaSnapList)).ShouldNot(BeZero())
+
+		Expect(ssr.ValidateBackup(testCtx)).ShouldNot(HaveOccurred())
+	})
+})
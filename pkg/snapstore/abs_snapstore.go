@@ -20,12 +20,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/sirupsen/logrus"
@@ -36,6 +39,18 @@ import (
 const (
 	absCredentialFile     = "AZURE_APPLICATION_CREDENTIALS"
 	absCredentialJSONFile = "AZURE_APPLICATION_CREDENTIALS_JSON"
+
+	// absStorageResource is the resource an access token is requested for when
+	// authenticating via a managed identity.
+	absStorageResource = "https://storage.azure.com/"
+	// absIMDSTokenEndpoint is the token endpoint of the Azure instance metadata service.
+	absIMDSTokenEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// absIMDSAPIVersion is the API version to use against the instance metadata service.
+	absIMDSAPIVersion = "2018-02-01"
+	// absTokenRefreshMargin is how long before expiry a managed identity token is refreshed.
+	absTokenRefreshMargin = 5 * time.Minute
+	// absTokenRetryInterval is how long to wait before retrying a failed token refresh.
+	absTokenRetryInterval = 30 * time.Second
 )
 
 // ABSSnapStore is an ABS backed snapstore.
@@ -51,52 +66,136 @@ type absCredentials struct {
 	BucketName     string `json:"bucketName"`
 	SecretKey      string `json:"storageKey"`
 	StorageAccount string `json:"storageAccount"`
+	SASToken       string `json:"sasToken"`
 }
 
 // NewABSSnapStore create new ABSSnapStore from shared configuration with specified bucket
 func NewABSSnapStore(config *brtypes.SnapstoreConfig) (*ABSSnapStore, error) {
-	storageAccount, storageKey, err := getCredentials(getEnvPrefixString(config.IsSource))
+	credentials, err := getCredentials(getEnvPrefixString(config.IsSource))
 	if err != nil {
 		return nil, err
 	}
 
-	credentials, err := azblob.NewSharedKeyCredential(storageAccount, storageKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create shared key credentials: %v", err)
+	// Auto-detect the authentication mode from the configured credentials:
+	// a storage key takes precedence, then a SAS token, and if neither is
+	// configured the managed identity of the environment is used.
+	var credential azblob.Credential
+	switch {
+	case len(credentials.SecretKey) != 0:
+		credential, err = azblob.NewSharedKeyCredential(credentials.StorageAccount, credentials.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create shared key credentials: %v", err)
+		}
+	case len(credentials.SASToken) != 0:
+		// The SAS token authorizes requests via the URL query parameters,
+		// so the pipeline itself carries no credential.
+		credential = azblob.NewAnonymousCredential()
+	default:
+		credential = newManagedIdentityTokenCredential()
 	}
 
-	p := azblob.NewPipeline(credentials, azblob.PipelineOptions{
+	p := azblob.NewPipeline(credential, azblob.PipelineOptions{
 		Retry: azblob.RetryOptions{
 			TryTimeout: downloadTimeout,
 		}})
-	u, err := url.Parse(fmt.Sprintf("https://%s.%s", storageAccount, brtypes.AzureBlobStorageHostName))
+	u, err := url.Parse(fmt.Sprintf("https://%s.%s", credentials.StorageAccount, brtypes.AzureBlobStorageHostName))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse service url: %v", err)
 	}
+	if len(credentials.SecretKey) == 0 && len(credentials.SASToken) != 0 {
+		u.RawQuery = strings.TrimPrefix(credentials.SASToken, "?")
+	}
 	serviceURL := azblob.NewServiceURL(*u, p)
 	containerURL := serviceURL.NewContainerURL(config.Container)
 	return GetABSSnapstoreFromClient(config.Container, config.Prefix, config.TempDir, config.MaxParallelChunkUploads, &containerURL)
 }
 
-func getCredentials(prefixString string) (string, string, error) {
+func getCredentials(prefixString string) (*absCredentials, error) {
 
 	if filename, isSet := os.LookupEnv(prefixString + absCredentialJSONFile); isSet {
 		credentials, err := readABSCredentialsJSON(filename)
 		if err != nil {
-			return "", "", fmt.Errorf("error getting credentials using %v file", filename)
+			return nil, fmt.Errorf("error getting credentials using %v file", filename)
 		}
-		return credentials.StorageAccount, credentials.SecretKey, nil
+		return credentials, nil
 	}
 
 	if dir, isSet := os.LookupEnv(prefixString + absCredentialFile); isSet {
 		credentials, err := readABSCredentialFiles(dir)
 		if err != nil {
-			return "", "", fmt.Errorf("error getting credentials from %v dir", dir)
+			return nil, fmt.Errorf("error getting credentials from %v dir", dir)
 		}
-		return credentials.StorageAccount, credentials.SecretKey, nil
+		return credentials, nil
 	}
 
-	return "", "", fmt.Errorf("unable to get credentials")
+	return nil, fmt.Errorf("unable to get credentials")
+}
+
+// newManagedIdentityTokenCredential returns a token credential backed by the
+// managed identity of the environment the process runs in. The token is
+// refreshed shortly before it expires so long-running processes keep working.
+func newManagedIdentityTokenCredential() azblob.TokenCredential {
+	return azblob.NewTokenCredential("", func(credential azblob.TokenCredential) time.Duration {
+		token, validity, err := getManagedIdentityToken()
+		if err != nil {
+			logrus.Errorf("Failed to refresh managed identity token for azure object storage: %v", err)
+			return absTokenRetryInterval
+		}
+		credential.SetToken(token)
+		if validity > absTokenRefreshMargin {
+			return validity - absTokenRefreshMargin
+		}
+		return validity / 2
+	})
+}
+
+// managedIdentityTokenResponse is the relevant subset of the token response
+// returned by the Azure instance metadata service.
+type managedIdentityTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   string `json:"expires_in"`
+}
+
+// getManagedIdentityToken fetches an access token for the storage resource
+// from the Azure instance metadata service, optionally for the user-assigned
+// identity selected via the AZURE_CLIENT_ID environment variable.
+func getManagedIdentityToken() (string, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodGet, absIMDSTokenEndpoint, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	q := req.URL.Query()
+	q.Set("api-version", absIMDSAPIVersion)
+	q.Set("resource", absStorageResource)
+	if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
+		q.Set("client_id", clientID)
+	}
+	req.URL.RawQuery = q.Encode()
+	req.Header.Set("Metadata", "true")
+
+	ctx, cancel := context.WithTimeout(context.TODO(), providerConnectionTimeout)
+	defer cancel()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("instance metadata service returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	tokenResponse := &managedIdentityTokenResponse{}
+	if err := json.Unmarshal(body, tokenResponse); err != nil {
+		return "", 0, fmt.Errorf("failed to parse token response: %v", err)
+	}
+	expiresIn, err := strconv.Atoi(tokenResponse.ExpiresIn)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse token validity: %v", err)
+	}
+	return tokenResponse.AccessToken, time.Duration(expiresIn) * time.Second, nil
 }
 
 func readABSCredentialsJSON(filename string) (*absCredentials, error) {
@@ -139,6 +238,12 @@ func readABSCredentialFiles(dirname string) (*absCredentials, error) {
 				return nil, err
 			}
 			absConfig.SecretKey = string(data)
+		} else if file.Name() == "sasToken" {
+			data, err := os.ReadFile(dirname + "/sasToken")
+			if err != nil {
+				return nil, err
+			}
+			absConfig.SASToken = string(data)
 		}
 	}
 
@@ -370,13 +475,15 @@ func ABSSnapStoreHash(config *brtypes.SnapstoreConfig) (string, error) {
 }
 
 func getABSHash(config *absCredentials) string {
-	data := fmt.Sprintf("%s%s", config.SecretKey, config.StorageAccount)
+	data := fmt.Sprintf("%s%s%s", config.SecretKey, config.StorageAccount, config.SASToken)
 	return getHash(data)
 }
 
 func isABSConfigEmpty(config *absCredentials) error {
-	if len(config.SecretKey) != 0 && len(config.StorageAccount) != 0 {
+	// A storage key and SAS token are optional: when neither is configured
+	// the managed identity of the environment is used for authentication.
+	if len(config.StorageAccount) != 0 {
 		return nil
 	}
-	return fmt.Errorf("azure object storage credentials: storageKey or storageAccount is missing")
+	return fmt.Errorf("azure object storage credentials: storageAccount is missing")
 }
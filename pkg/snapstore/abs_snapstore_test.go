@@ -36,6 +36,12 @@ import (
 )
 
 func newFakeABSSnapstore() brtypes.SnapStore {
+	return newFakeABSSnapstoreForQuery("")
+}
+
+// newFakeABSSnapstoreForQuery builds a fake ABS snapstore whose service URL carries
+// the given raw query, mirroring how a SAS token is attached to the request URLs.
+func newFakeABSSnapstoreForQuery(rawQuery string) brtypes.SnapStore {
 	f := []pipeline.Factory{
 		pipeline.MethodFactoryMarker(),
 		newFakePolicyFactory(bucket, prefixV2, objectMap),
@@ -43,6 +49,7 @@ func newFakeABSSnapstore() brtypes.SnapStore {
 	p := pipeline.NewPipeline(f, pipeline.Options{HTTPSender: newFakePolicyFactory(bucket, prefixV2, objectMap)})
 	u, err := url.Parse(fmt.Sprintf("https://%s.%s", "dummyaccount", brtypes.AzureBlobStorageHostName))
 	Expect(err).ShouldNot(HaveOccurred())
+	u.RawQuery = rawQuery
 	serviceURL := azblob.NewServiceURL(*u, p)
 	containerURL := serviceURL.NewContainerURL(bucket)
 	a, err := GetABSSnapstoreFromClient(bucket, prefixV2, "/tmp", 5, &containerURL)
@@ -0,0 +1,121 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/metrics"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CachingSnapStore is a snapstore wrapper which caches the snapshot listing of
+// the backing store for a short TTL to reduce the number of listing calls made
+// against the storage provider API. The cache is invalidated whenever a Save or
+// Delete is performed through this wrapper. It is safe for concurrent use.
+type CachingSnapStore struct {
+	store brtypes.SnapStore
+	ttl   time.Duration
+	// mutex guards the cached listing and serializes listing calls to the
+	// backing store so that concurrent readers do not list it redundantly.
+	mutex    sync.Mutex
+	snapList brtypes.SnapList
+	listedAt time.Time
+}
+
+// NewCachingSnapStore returns a caching wrapper around the given snapstore
+// which serves snapshot listings from a cache with the given TTL.
+func NewCachingSnapStore(store brtypes.SnapStore, ttl time.Duration) *CachingSnapStore {
+	return &CachingSnapStore{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Fetch should open reader for the snapshot file from store
+func (s *CachingSnapStore) Fetch(snap brtypes.Snapshot) (io.ReadCloser, error) {
+	return s.store.Fetch(snap)
+}
+
+// List will return sorted list with all snapshot files on store. The listing is
+// served from the cache if it has not yet expired, otherwise it is fetched from
+// the backing store and cached.
+func (s *CachingSnapStore) List() (brtypes.SnapList, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.snapList != nil && time.Since(s.listedAt) < s.ttl {
+		return append(brtypes.SnapList{}, s.snapList...), nil
+	}
+
+	metrics.SnapstoreListCallsTotal.With(prometheus.Labels{}).Inc()
+	snapList, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	s.snapList = snapList
+	s.listedAt = time.Now()
+	return append(brtypes.SnapList{}, snapList...), nil
+}
+
+// Save will write the snapshot to store and invalidate the cached listing.
+func (s *CachingSnapStore) Save(snap brtypes.Snapshot, rc io.ReadCloser) error {
+	err := s.store.Save(snap, rc)
+	s.invalidateCache()
+	return err
+}
+
+// Delete should delete the snapshot file from store and invalidate the cached listing.
+func (s *CachingSnapStore) Delete(snap brtypes.Snapshot) error {
+	err := s.store.Delete(snap)
+	s.invalidateCache()
+	return err
+}
+
+// AbortIncompleteUploads aborts the incomplete multipart uploads initiated
+// before the given time, if the backing store supports it, and is a no-op
+// otherwise.
+func (s *CachingSnapStore) AbortIncompleteUploads(before time.Time) error {
+	if store, ok := s.store.(brtypes.IncompleteUploadCleanerSnapStore); ok {
+		return store.AbortIncompleteUploads(before)
+	}
+	return nil
+}
+
+// SetBackingStore replaces the backing store, e.g. with a snapstore recreated
+// from rotated credentials, while retaining the cached listing.
+func (s *CachingSnapStore) SetBackingStore(store brtypes.SnapStore) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.store = store
+}
+
+// WrappedSnapStore returns the backing store, so that callers can discover
+// its optional capabilities, e.g. native pagination, which listings served
+// from the cache cannot provide.
+func (s *CachingSnapStore) WrappedSnapStore() brtypes.SnapStore {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.store
+}
+
+func (s *CachingSnapStore) invalidateCache() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.snapList = nil
+}
@@ -23,11 +23,17 @@ import (
 	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
 	"github.com/sirupsen/logrus"
 )
 
+// tmpSnapshotSuffix is appended to the object name while a snapshot is still
+// being written. Objects carrying the suffix are invisible to List and Fetch
+// and get cleaned up by AbortIncompleteUploads when they become stale.
+const tmpSnapshotSuffix = ".tmp"
+
 // LocalSnapStore is snapstore with local disk as backend
 type LocalSnapStore struct {
 	prefix string
@@ -51,23 +57,33 @@ func (s *LocalSnapStore) Fetch(snap brtypes.Snapshot) (io.ReadCloser, error) {
 	return os.Open(path.Join(snap.Prefix, snap.SnapDir, snap.SnapName))
 }
 
-// Save will write the snapshot to store
+// Save will write the snapshot to store. The snapshot is staged under a
+// temporary name and only renamed into place once it has been written
+// completely, so that an upload interrupted mid-way never leaves a partially
+// written snapshot visible to List or Fetch.
 func (s *LocalSnapStore) Save(snap brtypes.Snapshot, rc io.ReadCloser) error {
 	defer rc.Close()
 	err := os.MkdirAll(path.Join(s.prefix, snap.SnapDir), 0700)
 	if err != nil && !os.IsExist(err) {
 		return err
 	}
-	f, err := os.Create(path.Join(s.prefix, snap.SnapDir, snap.SnapName))
+	snapPath := path.Join(s.prefix, snap.SnapDir, snap.SnapName)
+	tmpPath := snapPath + tmpSnapshotSuffix
+	f, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = io.Copy(f, rc)
-	if err != nil {
+	if err := func() error {
+		defer f.Close()
+		if _, err := io.Copy(f, rc); err != nil {
+			return err
+		}
+		return f.Sync()
+	}(); err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
-	return f.Sync()
+	return os.Rename(tmpPath, snapPath)
 }
 
 // List will return sorted list with all snapshot files on store.
@@ -86,6 +102,11 @@ func (s *LocalSnapStore) List() (brtypes.SnapList, error) {
 		if info.IsDir() {
 			return nil
 		}
+		if strings.HasSuffix(path, tmpSnapshotSuffix) {
+			// Snapshot whose upload is still in progress, or a leftover of an
+			// interrupted upload awaiting cleanup.
+			return nil
+		}
 		if strings.Contains(path, backupVersionV1) || strings.Contains(path, backupVersionV2) {
 			snap, err := ParseSnapshot(path)
 			if err != nil {
@@ -117,9 +138,32 @@ func (s *LocalSnapStore) Delete(snap brtypes.Snapshot) error {
 	return nil
 }
 
+// AbortIncompleteUploads deletes temporary snapshot files that were staged
+// before the given time and never renamed into place, i.e. leftovers of
+// uploads that were interrupted mid-way. It is called by the garbage collector
+// at startup and on every garbage collection cycle.
+func (s *LocalSnapStore) AbortIncompleteUploads(before time.Time) error {
+	return filepath.Walk(s.prefix, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, tmpSnapshotSuffix) {
+			return nil
+		}
+		if info.ModTime().Before(before) {
+			logrus.Infof("Removing stale temporary snapshot file: %s", path)
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
 // Size should return size of the snapshot file from store
 func (s *LocalSnapStore) Size(snap brtypes.Snapshot) (int64, error) {
-	fileInfo, err := os.Stat(path.Join(s.prefix, snap.SnapDir, snap.SnapName))
+	fileInfo, err := os.Stat(path.Join(snap.Prefix, snap.SnapDir, snap.SnapName))
 	if err != nil {
 		return -1, err
 	}
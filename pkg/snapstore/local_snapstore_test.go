@@ -0,0 +1,143 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// failingReadCloser fails the snapshot upload mid-way to simulate an
+// interrupted upload.
+type failingReadCloser struct{}
+
+func (failingReadCloser) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("upload interrupted")
+}
+
+func (failingReadCloser) Close() error {
+	return nil
+}
+
+var _ = Describe("Staged snapshot uploads on local snapstore", func() {
+	var (
+		baseDir string
+		prefix  string
+		store   *LocalSnapStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		baseDir, err = os.MkdirTemp("", "localsnapstore")
+		Expect(err).ShouldNot(HaveOccurred())
+		prefix = path.Join(baseDir, "v2")
+		store, err = NewLocalSnapStore(prefix)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	listTmpFiles := func() []string {
+		var tmpFiles []string
+		err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(path, ".tmp") {
+				tmpFiles = append(tmpFiles, path)
+			}
+			return nil
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		return tmpFiles
+	}
+
+	It("should publish a snapshot only once it is completely written", func() {
+		snap := NewSnapshot(brtypes.SnapshotKindFull, 0, 1, "", false)
+		Expect(store.Save(*snap, io.NopCloser(strings.NewReader("snapshot contents")))).To(Succeed())
+
+		snapList, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList).To(HaveLen(1))
+		Expect(snapList[0].SnapName).To(Equal(snap.SnapName))
+		Expect(listTmpFiles()).To(BeEmpty())
+	})
+
+	It("should not leave a partially written snapshot behind when the upload fails", func() {
+		snap := NewSnapshot(brtypes.SnapshotKindFull, 0, 1, "", false)
+		Expect(store.Save(*snap, failingReadCloser{})).ShouldNot(Succeed())
+
+		snapList, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList).To(BeEmpty())
+		Expect(listTmpFiles()).To(BeEmpty())
+	})
+
+	It("should ignore temporary snapshot objects while listing", func() {
+		snap := NewSnapshot(brtypes.SnapshotKindFull, 0, 1, "", false)
+		Expect(store.Save(*snap, io.NopCloser(strings.NewReader("snapshot contents")))).To(Succeed())
+
+		staged := NewSnapshot(brtypes.SnapshotKindFull, 2, 3, "", false)
+		stagedPath := path.Join(prefix, staged.SnapDir, staged.SnapName+".tmp")
+		Expect(os.MkdirAll(path.Dir(stagedPath), 0700)).To(Succeed())
+		Expect(os.WriteFile(stagedPath, []byte("partial contents"), 0600)).To(Succeed())
+
+		snapList, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList).To(HaveLen(1))
+		Expect(snapList[0].SnapName).To(Equal(snap.SnapName))
+	})
+
+	It("should delete only the stale temporary snapshot objects", func() {
+		var _ brtypes.IncompleteUploadCleanerSnapStore = store
+
+		snap := NewSnapshot(brtypes.SnapshotKindFull, 0, 1, "", false)
+		Expect(store.Save(*snap, io.NopCloser(strings.NewReader("snapshot contents")))).To(Succeed())
+
+		stale := NewSnapshot(brtypes.SnapshotKindFull, 2, 3, "", false)
+		stalePath := path.Join(prefix, stale.SnapDir, stale.SnapName+".tmp")
+		Expect(os.MkdirAll(path.Dir(stalePath), 0700)).To(Succeed())
+		Expect(os.WriteFile(stalePath, []byte("partial contents"), 0600)).To(Succeed())
+		staleTime := time.Now().Add(-48 * time.Hour)
+		Expect(os.Chtimes(stalePath, staleTime, staleTime)).To(Succeed())
+
+		recent := NewSnapshot(brtypes.SnapshotKindFull, 4, 5, "", false)
+		recentPath := path.Join(prefix, recent.SnapDir, recent.SnapName+".tmp")
+		Expect(os.MkdirAll(path.Dir(recentPath), 0700)).To(Succeed())
+		Expect(os.WriteFile(recentPath, []byte("partial contents"), 0600)).To(Succeed())
+
+		Expect(store.AbortIncompleteUploads(time.Now().Add(-24 * time.Hour))).To(Succeed())
+
+		Expect(stalePath).ShouldNot(BeAnExistingFile())
+		Expect(recentPath).Should(BeAnExistingFile())
+		snapList, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList).To(HaveLen(1))
+		Expect(snapList[0].SnapName).To(Equal(snap.SnapName))
+	})
+})
@@ -0,0 +1,191 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/metrics"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// ReplicatedSnapStore is a snapstore wrapper which replicates every snapshot
+// written to the primary store to a set of replica stores, e.g. for
+// cross-region durability. A failed save to the primary store fails the
+// snapshot; replication failures are logged and counted per target but do not
+// fail the snapshot cycle, unless failOnReplicationError is set, in which case
+// the snapshot is replicated synchronously and a replication failure fails the
+// save. Deletions are propagated to the replica stores so that the garbage
+// collection policy applied through this wrapper takes effect on every store.
+type ReplicatedSnapStore struct {
+	store                  brtypes.SnapStore
+	replicas               []brtypes.SnapStore
+	failOnReplicationError bool
+	wg                     sync.WaitGroup
+}
+
+// NewReplicatedSnapStore returns a replicating wrapper around the given primary
+// snapstore which replicates every saved snapshot to the given replica stores.
+func NewReplicatedSnapStore(store brtypes.SnapStore, failOnReplicationError bool, replicas ...brtypes.SnapStore) *ReplicatedSnapStore {
+	return &ReplicatedSnapStore{
+		store:                  store,
+		replicas:               replicas,
+		failOnReplicationError: failOnReplicationError,
+	}
+}
+
+// Fetch should open reader for the snapshot file from the primary store.
+func (s *ReplicatedSnapStore) Fetch(snap brtypes.Snapshot) (io.ReadCloser, error) {
+	return s.store.Fetch(snap)
+}
+
+// List will return sorted list with all snapshot files on the primary store.
+func (s *ReplicatedSnapStore) List() (brtypes.SnapList, error) {
+	return s.store.List()
+}
+
+// WrappedSnapStore returns the primary store, so that callers can discover
+// its optional capabilities, e.g. native pagination of listings, which are
+// served from the primary store only.
+func (s *ReplicatedSnapStore) WrappedSnapStore() brtypes.SnapStore {
+	return s.store
+}
+
+// Save will write the snapshot to the primary store and replicate it to the
+// replica stores, synchronously when failOnReplicationError is set and in the
+// background otherwise.
+func (s *ReplicatedSnapStore) Save(snap brtypes.Snapshot, rc io.ReadCloser) error {
+	if err := s.store.Save(snap, rc); err != nil {
+		return err
+	}
+	if s.failOnReplicationError {
+		for i := range s.replicas {
+			if err := s.replicate(snap, i); err != nil {
+				return fmt.Errorf("failed to replicate snapshot %s to replica snapstore %d: %v", snap.SnapName, i, err)
+			}
+		}
+		return nil
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for i := range s.replicas {
+			if err := s.replicate(snap, i); err != nil {
+				logrus.Errorf("Failed to replicate snapshot %s to replica snapstore %d: %v", snap.SnapName, i, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// replicate streams the given snapshot from the primary store to the replica
+// store with the given index and counts the outcome per target.
+func (s *ReplicatedSnapStore) replicate(snap brtypes.Snapshot, index int) error {
+	target := fmt.Sprintf("%d", index)
+	stored, err := s.findStoredSnapshot(s.store, snap)
+	if err == nil && stored == nil {
+		err = fmt.Errorf("snapshot %s not found in the primary store", snap.SnapName)
+	}
+	if err == nil {
+		var rc io.ReadCloser
+		rc, err = s.store.Fetch(*stored)
+		if err == nil {
+			defer rc.Close()
+			err = s.replicas[index].Save(snap, rc)
+		}
+	}
+	if err != nil {
+		metrics.SnapshotReplicationTotal.With(prometheus.Labels{metrics.LabelTarget: target, metrics.LabelSucceeded: metrics.ValueSucceededFalse}).Inc()
+		return err
+	}
+	metrics.SnapshotReplicationTotal.With(prometheus.Labels{metrics.LabelTarget: target, metrics.LabelSucceeded: metrics.ValueSucceededTrue}).Inc()
+	return nil
+}
+
+// Delete should delete the snapshot file from the primary store and propagate
+// the deletion to the replica stores, so that garbage collection through this
+// wrapper applies to every store. A failed replica deletion is only logged, as
+// the snapshot may never have reached the replica in the first place.
+func (s *ReplicatedSnapStore) Delete(snap brtypes.Snapshot) error {
+	if err := s.store.Delete(snap); err != nil {
+		return err
+	}
+	for i, replica := range s.replicas {
+		if err := s.deleteFromReplica(replica, snap); err != nil {
+			logrus.Warnf("Failed to delete snapshot %s from replica snapstore %d: %v", snap.SnapName, i, err)
+		}
+	}
+	return nil
+}
+
+// deleteFromReplica deletes the given snapshot from the replica store, resolving
+// it in the replica's own listing first since the snapshot passed to Delete
+// carries the primary store's prefix. A snapshot that never reached the replica
+// is not an error.
+func (s *ReplicatedSnapStore) deleteFromReplica(replica brtypes.SnapStore, snap brtypes.Snapshot) error {
+	stored, err := s.findStoredSnapshot(replica, snap)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return nil
+	}
+	return replica.Delete(*stored)
+}
+
+// findStoredSnapshot resolves the given snapshot to its stored representation
+// in the given store's listing, which carries the store's own prefix needed to
+// fetch or delete it. It returns nil if the store does not hold the snapshot.
+func (s *ReplicatedSnapStore) findStoredSnapshot(store brtypes.SnapStore, snap brtypes.Snapshot) (*brtypes.Snapshot, error) {
+	snapList, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, stored := range snapList {
+		if stored.SnapName == snap.SnapName && stored.SnapDir == snap.SnapDir {
+			return stored, nil
+		}
+	}
+	return nil, nil
+}
+
+// AbortIncompleteUploads aborts the incomplete multipart uploads initiated
+// before the given time on every store that supports it.
+func (s *ReplicatedSnapStore) AbortIncompleteUploads(before time.Time) error {
+	if store, ok := s.store.(brtypes.IncompleteUploadCleanerSnapStore); ok {
+		if err := store.AbortIncompleteUploads(before); err != nil {
+			return err
+		}
+	}
+	for i, replica := range s.replicas {
+		if store, ok := replica.(brtypes.IncompleteUploadCleanerSnapStore); ok {
+			if err := store.AbortIncompleteUploads(before); err != nil {
+				logrus.Warnf("Failed to abort incomplete uploads on replica snapstore %d: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WaitForReplication blocks until all background replications started so far
+// have finished.
+func (s *ReplicatedSnapStore) WaitForReplication() {
+	s.wg.Wait()
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Replicated snapstore", func() {
+	var (
+		primary    *LocalSnapStore
+		replica    *LocalSnapStore
+		replicated *ReplicatedSnapStore
+		snap       brtypes.Snapshot
+	)
+
+	BeforeEach(func() {
+		var err error
+		primaryDir, err := os.MkdirTemp("", "primary-snapstore-")
+		Expect(err).ShouldNot(HaveOccurred())
+		replicaDir, err := os.MkdirTemp("", "replica-snapstore-")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		primary, err = NewLocalSnapStore(path.Join(primaryDir, "v2"))
+		Expect(err).ShouldNot(HaveOccurred())
+		replica, err = NewLocalSnapStore(path.Join(replicaDir, "v2"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		replicated = NewReplicatedSnapStore(primary, false, replica)
+		snap = *NewSnapshot(brtypes.SnapshotKindFull, 0, 10, "", false)
+	})
+
+	It("should replicate a saved snapshot to the replica store", func() {
+		err := replicated.Save(snap, io.NopCloser(bytes.NewReader([]byte("dummy-snapshot-content"))))
+		Expect(err).ShouldNot(HaveOccurred())
+		replicated.WaitForReplication()
+
+		snapList, err := replica.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList).Should(HaveLen(1))
+		Expect(snapList[0].SnapName).Should(Equal(snap.SnapName))
+
+		rc, err := replica.Fetch(*snapList[0])
+		Expect(err).ShouldNot(HaveOccurred())
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(data)).Should(Equal("dummy-snapshot-content"))
+	})
+
+	It("should propagate deletions to the replica store", func() {
+		err := replicated.Save(snap, io.NopCloser(bytes.NewReader([]byte("dummy-snapshot-content"))))
+		Expect(err).ShouldNot(HaveOccurred())
+		replicated.WaitForReplication()
+
+		// Garbage collection deletes snapshots as listed, carrying the
+		// primary store's prefix.
+		snapList, err := replicated.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList).Should(HaveLen(1))
+		Expect(replicated.Delete(*snapList[0])).ShouldNot(HaveOccurred())
+
+		for _, store := range []*LocalSnapStore{primary, replica} {
+			snapList, err := store.List()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(snapList).Should(BeEmpty())
+		}
+	})
+
+	It("should not fail the save on a replication failure by default", func() {
+		replicated = NewReplicatedSnapStore(primary, false, failingSnapStore{})
+
+		err := replicated.Save(snap, io.NopCloser(bytes.NewReader([]byte("dummy-snapshot-content"))))
+		Expect(err).ShouldNot(HaveOccurred())
+		replicated.WaitForReplication()
+
+		snapList, err := primary.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList).Should(HaveLen(1))
+	})
+
+	It("should fail the save on a replication failure when configured to", func() {
+		replicated = NewReplicatedSnapStore(primary, true, failingSnapStore{})
+
+		err := replicated.Save(snap, io.NopCloser(bytes.NewReader([]byte("dummy-snapshot-content"))))
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("failed to replicate"))
+	})
+})
+
+// failingSnapStore is a snapstore stub whose operations always fail.
+type failingSnapStore struct{}
+
+func (failingSnapStore) Fetch(brtypes.Snapshot) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("fetch failed")
+}
+
+func (failingSnapStore) List() (brtypes.SnapList, error) {
+	return nil, fmt.Errorf("list failed")
+}
+
+func (failingSnapStore) Save(brtypes.Snapshot, io.ReadCloser) error {
+	return fmt.Errorf("save failed")
+}
+
+func (failingSnapStore) Delete(brtypes.Snapshot) error {
+	return fmt.Errorf("delete failed")
+}
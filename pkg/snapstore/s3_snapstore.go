@@ -388,6 +388,18 @@ func (s *S3SnapStore) Save(snap brtypes.Snapshot, rc io.ReadCloser) error {
 	}
 
 	if err != nil {
+		if snapshotErr == nil {
+			// Abort the upload when completing it failed, so that no orphaned
+			// parts are left behind in the bucket.
+			logrus.Infof("Aborting the multipart upload with upload ID : %s", *uploadOutput.UploadId)
+			if _, abortErr := s.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   &s.bucket,
+				Key:      aws.String(path.Join(prefix, snap.SnapDir, snap.SnapName)),
+				UploadId: uploadOutput.UploadId,
+			}); abortErr != nil {
+				logrus.Warnf("failed to abort multipart upload with upload ID %s: %v", *uploadOutput.UploadId, abortErr)
+			}
+		}
 		return fmt.Errorf("failed completing snapshot upload with error %v", err)
 	}
 	if snapshotErr != nil {
@@ -484,6 +496,50 @@ func (s *S3SnapStore) List() (brtypes.SnapList, error) {
 	return snapList, nil
 }
 
+// ListPage returns one page of at most limit snapshots from the store along with
+// the continuation token for the next page. Pages are served directly from the
+// backend listing, so the complete listing is never held in memory.
+func (s *S3SnapStore) ListPage(token string, limit int64) (brtypes.SnapList, string, error) {
+	prefixTokens := strings.Split(s.prefix, "/")
+	// Last element of the tokens is backup version
+	// Consider the parent of the backup version level (Required for Backward Compatibility)
+	prefix := path.Join(strings.Join(prefixTokens[:len(prefixTokens)-1], "/"))
+
+	in := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int64(limit),
+	}
+	if token != "" {
+		in.ContinuationToken = aws.String(token)
+	}
+	page, err := s.client.ListObjectsV2(in)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var snapList brtypes.SnapList
+	for _, key := range page.Contents {
+		k := (*key.Key)[len(*page.Prefix):]
+		if strings.Contains(k, backupVersionV1) || strings.Contains(k, backupVersionV2) {
+			snap, err := ParseSnapshot(path.Join(prefix, k))
+			if err != nil {
+				// Warning
+				logrus.Warnf("Invalid snapshot found. Ignoring it: %s", k)
+			} else {
+				snapList = append(snapList, snap)
+			}
+		}
+	}
+	sort.Sort(snapList)
+
+	var nextToken string
+	if page.NextContinuationToken != nil {
+		nextToken = *page.NextContinuationToken
+	}
+	return snapList, nextToken, nil
+}
+
 // Delete should delete the snapshot file from store
 func (s *S3SnapStore) Delete(snap brtypes.Snapshot) error {
 	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
@@ -493,6 +549,42 @@ func (s *S3SnapStore) Delete(snap brtypes.Snapshot) error {
 	return err
 }
 
+// AbortIncompleteUploads lists the multipart uploads under the store prefix that
+// were initiated before the given time and aborts them. It is called by the
+// garbage collector to remove orphaned parts left behind by interrupted uploads.
+func (s *S3SnapStore) AbortIncompleteUploads(before time.Time) error {
+	prefixTokens := strings.Split(s.prefix, "/")
+	// Last element of the tokens is backup version
+	// Consider the parent of the backup version level (Required for Backward Compatibility)
+	prefix := path.Join(strings.Join(prefixTokens[:len(prefixTokens)-1], "/"))
+
+	var abortErr error
+	in := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := s.client.ListMultipartUploadsPages(in, func(page *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+		for _, upload := range page.Uploads {
+			if upload.Initiated == nil || !upload.Initiated.Before(before) {
+				continue
+			}
+			logrus.Infof("Aborting incomplete multipart upload with upload ID : %s for key: %s", *upload.UploadId, *upload.Key)
+			if _, err := s.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(s.bucket),
+				Key:      upload.Key,
+				UploadId: upload.UploadId,
+			}); err != nil {
+				abortErr = fmt.Errorf("failed to abort multipart upload with upload ID %s: %v", *upload.UploadId, err)
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return err
+	}
+	return abortErr
+}
+
 // S3SnapStoreHash calculates and returns the hash of aws S3 snapstore secret.
 func S3SnapStoreHash(config *brtypes.SnapstoreConfig) (string, error) {
 	if _, isSet := os.LookupEnv(awsCredentialFile); isSet {
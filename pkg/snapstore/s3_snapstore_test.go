@@ -35,6 +35,7 @@ type mockS3Client struct {
 	objects               map[string]*[]byte
 	prefix                string
 	multiPartUploads      map[string]*[][]byte
+	multiPartUploadsMeta  map[string]*s3.MultipartUpload
 	multiPartUploadsMutex sync.Mutex
 }
 
@@ -72,6 +73,14 @@ func (m *mockS3Client) CreateMultipartUploadWithContext(ctx aws.Context, in *s3.
 	uploadID := time.Now().String()
 	var parts [][]byte
 	m.multiPartUploads[uploadID] = &parts
+	if m.multiPartUploadsMeta == nil {
+		m.multiPartUploadsMeta = map[string]*s3.MultipartUpload{}
+	}
+	m.multiPartUploadsMeta[uploadID] = &s3.MultipartUpload{
+		Key:       in.Key,
+		UploadId:  &uploadID,
+		Initiated: aws.Time(time.Now()),
+	}
 	out := &s3.CreateMultipartUploadOutput{
 		Bucket:   in.Bucket,
 		UploadId: &uploadID,
@@ -135,6 +144,7 @@ func (m *mockS3Client) CompleteMultipartUploadWithContext(ctx aws.Context, in *s
 	}
 	m.objects[*in.Key] = &object
 	delete(m.multiPartUploads, *in.UploadId)
+	delete(m.multiPartUploadsMeta, *in.UploadId)
 	eTag := time.Now().String()
 	out := s3.CompleteMultipartUploadOutput{
 		Bucket: in.Bucket,
@@ -145,10 +155,31 @@ func (m *mockS3Client) CompleteMultipartUploadWithContext(ctx aws.Context, in *s
 
 func (m *mockS3Client) AbortMultipartUploadWithContext(ctx aws.Context, in *s3.AbortMultipartUploadInput, opts ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
 	delete(m.multiPartUploads, *in.UploadId)
+	delete(m.multiPartUploadsMeta, *in.UploadId)
 	out := &s3.AbortMultipartUploadOutput{}
 	return out, nil
 }
 
+// AbortMultipartUpload aborts the multipart upload for mock test
+func (m *mockS3Client) AbortMultipartUpload(in *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	return m.AbortMultipartUploadWithContext(aws.BackgroundContext(), in)
+}
+
+// ListMultipartUploadsPages returns the in-progress multipart uploads for mock test
+func (m *mockS3Client) ListMultipartUploadsPages(in *s3.ListMultipartUploadsInput, callback func(*s3.ListMultipartUploadsOutput, bool) bool) error {
+	out := &s3.ListMultipartUploadsOutput{
+		Prefix: in.Prefix,
+	}
+	for _, upload := range m.multiPartUploadsMeta {
+		if in.Prefix != nil && !strings.HasPrefix(*upload.Key, *in.Prefix) {
+			continue
+		}
+		out.Uploads = append(out.Uploads, upload)
+	}
+	callback(out, true)
+	return nil
+}
+
 // ListObject returns the objects from map for mock test
 func (m *mockS3Client) ListObjects(in *s3.ListObjectsInput) (*s3.ListObjectsOutput, error) {
 	var contents []*s3.Object
@@ -217,6 +248,49 @@ func (m *mockS3Client) ListObjectsPages(in *s3.ListObjectsInput, callback func(*
 	return nil
 }
 
+// ListObjectsV2 returns one page of objects from the map for mock test. The
+// continuation token is the last key of the previous page.
+func (m *mockS3Client) ListObjectsV2(in *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	var (
+		limit int64 = 1000 // aws default
+		keys  []string
+	)
+	if in.MaxKeys != nil {
+		limit = *in.MaxKeys
+	}
+	for key := range m.objects {
+		if strings.HasPrefix(key, *in.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if in.ContinuationToken != nil {
+		index := sort.SearchStrings(keys, *in.ContinuationToken)
+		if index < len(keys) && keys[index] == *in.ContinuationToken {
+			index++
+		}
+		keys = keys[index:]
+	}
+
+	out := &s3.ListObjectsV2Output{
+		Prefix:   in.Prefix,
+		Contents: make([]*s3.Object, 0),
+	}
+	for _, key := range keys {
+		if int64(len(out.Contents)) == limit {
+			out.NextContinuationToken = out.Contents[len(out.Contents)-1].Key
+			break
+		}
+		keyPtr := new(string)
+		*keyPtr = key
+		out.Contents = append(out.Contents, &s3.Object{
+			Key: keyPtr,
+		})
+	}
+	return out, nil
+}
+
 // DeleteObject deletes the object from map for mock test
 func (m *mockS3Client) DeleteObject(in *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
 	delete(m.objects, *in.Key)
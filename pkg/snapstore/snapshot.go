@@ -69,11 +69,19 @@ func ParseSnapshot(snapPath string) (*brtypes.Snapshot, error) {
 	snapPath = snapPath[lastIndex:]
 
 	tok := strings.Split(snapPath, "/")
+
+	var snapName, snapDir string = "", ""
+	// Snapshots stored with the date object layout carry a YYYY/MM/DD
+	// directory between the backup version and the snapshot name.
+	if backupVersion == backupVersionV2 && len(tok) > 3 && isDateDirectory(tok[0], tok[1], tok[2]) {
+		snapDir = path.Join(tok[0], tok[1], tok[2])
+		tok = tok[3:]
+	}
+
 	if len(tok) < 1 || len(tok) > 3 {
 		return nil, fmt.Errorf("invalid snapshot name: %s", snapPath)
 	}
 
-	var snapName, snapDir string = "", ""
 	// Get snap name from the tokens
 	// Consider the token before snap name
 	// If it's v1, then consider the token as snapDir
@@ -144,3 +152,12 @@ func ParseSnapshot(snapPath string) (*brtypes.Snapshot, error) {
 	s.Prefix = prefix
 	return s, nil
 }
+
+// isDateDirectory reports whether the given path tokens form a YYYY/MM/DD date directory.
+func isDateDirectory(year, month, day string) bool {
+	if len(year) != 4 || len(month) != 2 || len(day) != 2 {
+		return false
+	}
+	_, err := time.Parse("2006/01/02", path.Join(year, month, day))
+	return err == nil
+}
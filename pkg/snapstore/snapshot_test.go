@@ -189,6 +189,31 @@ var _ = Describe("Snapshot", func() {
 				Expect(snap.SnapName).To(Equal("Full-00000000-00002088-2387428"))
 			})
 		})
+		Context("when path with backup version v2 and a date directory specified", func() {
+			It("populate correct prefix, snapdir, snapname", func() {
+				snapPath := "/abc/v2/2018/02/12/Full-00000000-00002088-2387428"
+				snap, err := ParseSnapshot(snapPath)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(snap.Prefix).To(Equal("/abc/v2/"))
+				Expect(snap.SnapDir).To(Equal("2018/02/12"))
+				Expect(snap.SnapName).To(Equal("Full-00000000-00002088-2387428"))
+				Expect(snap.IsChunk).To(BeFalse())
+			})
+			It("marks chunks below a date directory as chunks", func() {
+				snapPath := "/abc/v2/2018/02/12/Full-00000000-00002088-2387428/0000000001"
+				snap, err := ParseSnapshot(snapPath)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(snap.Prefix).To(Equal("/abc/v2/"))
+				Expect(snap.SnapDir).To(Equal("2018/02/12"))
+				Expect(snap.SnapName).To(Equal("Full-00000000-00002088-2387428/0000000001"))
+				Expect(snap.IsChunk).To(BeTrue())
+			})
+			It("returns error for an invalid date directory", func() {
+				snapPath := "/abc/v2/2018/13/45/Full-00000000-00002088-2387428"
+				_, err := ParseSnapshot(snapPath)
+				Expect(err).Should(HaveOccurred())
+			})
+		})
 		Context("when path without any backup version specified", func() {
 			It("returns error", func() {
 				snapPath := "/abc/Full-00000000-00002088-2387428"
@@ -23,6 +23,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
 	. "github.com/gardener/etcd-backup-restore/pkg/snapstore"
 	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
 	fake "github.com/gophercloud/gophercloud/testhelper/client"
@@ -109,6 +111,8 @@ var _ = Describe("Save, List, Fetch, Delete from mock snapstore", func() {
 			}),
 			"swift": NewSwiftSnapstoreFromClient(bucket, prefixV2, "/tmp", 5, fake.ServiceClient()),
 			"ABS":   newFakeABSSnapstore(),
+			// ABS authenticated via a SAS token carried in the request URL query.
+			"ABS_SAS": newFakeABSSnapstoreForQuery("sv=2020-02-10&ss=b&srt=co&sp=rwdl&sig=dummysig"),
 			"GCS": NewGCSSnapStoreFromClient(bucket, prefixV2, "/tmp", 5, &mockGCSClient{
 				objects: objectMap,
 				prefix:  prefixV2,
@@ -234,6 +238,55 @@ var _ = Describe("Save, List, Fetch, Delete from mock snapstore", func() {
 		})
 	})
 
+	Describe("When v2 snapshots use the date object layout", func() {
+		It("round-trips snapshots through save, list, fetch and delete", func() {
+			snap4.GenerateDateDirectory()
+			snap5.GenerateDateDirectory()
+			for key, snapStore := range snapstores {
+				// Create store for mock tests
+				resetObjectMap()
+				objectMap[path.Join(prefixV2, snap4.SnapDir, snap4.SnapName)] = &expectedVal4
+				objectMap[path.Join(prefixV2, snap5.SnapDir, snap5.SnapName)] = &expectedVal5
+
+				logrus.Infof("Running mock tests for %s when v2 snapshots use the date object layout", key)
+				// List snap4 and snap5 with their date directories
+				snapList, err := snapStore.List()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(snapList.Len()).To(Equal(2))
+				Expect(snapList[0].SnapName).To(Equal(snap4.SnapName))
+				Expect(snapList[0].SnapDir).To(Equal(snap4.SnapDir))
+				Expect(snapList[1].SnapDir).To(Equal(snap5.SnapDir))
+				// Fetch snap5
+				rc, err := snapStore.Fetch(*snapList[1])
+				Expect(err).ShouldNot(HaveOccurred())
+				defer rc.Close()
+				buf := new(bytes.Buffer)
+				_, err = io.Copy(buf, rc)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(buf.Bytes()).To(Equal(expectedVal5))
+				// Delete snap5
+				prevLen := len(objectMap)
+				err = snapStore.Delete(*snapList[1])
+				Expect(err).ShouldNot(HaveOccurred())
+				snapList, err = snapStore.List()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(snapList.Len()).To(Equal(prevLen - 1))
+				// Save snapshot under its date directory
+				resetObjectMap()
+				dummyData := make([]byte, 6*1024*1024)
+				err = snapStore.Save(snap4, io.NopCloser(bytes.NewReader(dummyData)))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(objectMap)).Should(BeNumerically(">", 0))
+				snapList, err = snapStore.List()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(snapList.Len()).Should(BeNumerically(">", 0))
+				for _, snap := range snapList {
+					Expect(snap.SnapDir).To(Equal(snap4.SnapDir))
+				}
+			}
+		})
+	})
+
 	Describe("When Only v2 is present", func() {
 		It("When Only v2 is present", func() {
 			for key, snapStore := range snapstores {
@@ -274,6 +327,194 @@ var _ = Describe("Save, List, Fetch, Delete from mock snapstore", func() {
 	})
 })
 
+var _ = Describe("List snapshots in batches from mock snapstore", func() {
+	var (
+		snapCount int
+		dummyData []byte
+	)
+
+	BeforeEach(func() {
+		snapCount = 5
+		dummyData = []byte("dummy-snapshot-content")
+		resetObjectMap()
+		now := time.Now().Unix()
+		for i := 0; i < snapCount; i++ {
+			snap := brtypes.Snapshot{
+				CreatedOn:     time.Unix(now+int64(i*100), 0).UTC(),
+				StartRevision: 0,
+				LastRevision:  int64(1000 + i),
+				Kind:          brtypes.SnapshotKindFull,
+			}
+			snap.GenerateSnapshotName()
+			objectMap[path.Join(prefixV2, snap.SnapName)] = &dummyData
+		}
+	})
+	AfterEach(func() {
+		resetObjectMap()
+	})
+
+	It("should fetch batches through native pagination when supported by the store", func() {
+		store := NewS3FromClient(bucket, prefixV2, "/tmp", 5, &mockS3Client{
+			objects:          objectMap,
+			prefix:           prefixV2,
+			multiPartUploads: map[string]*[][]byte{},
+		})
+		var _ brtypes.PaginatedSnapStore = store
+
+		var (
+			batchCount int
+			snapList   brtypes.SnapList
+		)
+		err := ListSnapshotsInBatches(store, 2, func(batch brtypes.SnapList) error {
+			Expect(len(batch)).Should(BeNumerically("<=", 2))
+			batchCount++
+			snapList = append(snapList, batch...)
+			return nil
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(batchCount).To(Equal(3))
+		Expect(snapList.Len()).To(Equal(snapCount))
+	})
+
+	It("should fall back to a full listing for stores without native pagination", func() {
+		store := NewGCSSnapStoreFromClient(bucket, prefixV2, "/tmp", 5, &mockGCSClient{
+			objects: objectMap,
+			prefix:  prefixV2,
+		})
+
+		var (
+			batchCount int
+			snapList   brtypes.SnapList
+		)
+		err := ListSnapshotsInBatches(store, 2, func(batch brtypes.SnapList) error {
+			Expect(len(batch)).Should(BeNumerically("<=", 2))
+			batchCount++
+			snapList = append(snapList, batch...)
+			return nil
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(batchCount).To(Equal(3))
+		Expect(snapList.Len()).To(Equal(snapCount))
+	})
+})
+
+var _ = Describe("Caching snapstore on mock snapstore", func() {
+	var (
+		dummyData []byte
+		snap      brtypes.Snapshot
+		backing   *countingSnapStore
+		store     *CachingSnapStore
+	)
+
+	BeforeEach(func() {
+		dummyData = []byte("dummy-snapshot-content")
+		resetObjectMap()
+		snap = brtypes.Snapshot{
+			CreatedOn:     time.Now().UTC(),
+			StartRevision: 0,
+			LastRevision:  1000,
+			Kind:          brtypes.SnapshotKindFull,
+		}
+		snap.GenerateSnapshotName()
+		objectMap[path.Join(prefixV2, snap.SnapName)] = &dummyData
+		backing = &countingSnapStore{
+			SnapStore: NewS3FromClient(bucket, prefixV2, "/tmp", 5, &mockS3Client{
+				objects:          objectMap,
+				prefix:           prefixV2,
+				multiPartUploads: map[string]*[][]byte{},
+			}),
+		}
+		store = NewCachingSnapStore(backing, time.Minute)
+	})
+	AfterEach(func() {
+		resetObjectMap()
+	})
+
+	It("should serve repeated listings from the cache", func() {
+		snapList, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList.Len()).To(Equal(1))
+		snapList, err = store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList.Len()).To(Equal(1))
+		Expect(backing.listCalls).To(Equal(1))
+	})
+
+	It("should invalidate the cache on save and delete", func() {
+		snapList, err := store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList.Len()).To(Equal(1))
+
+		newSnap := brtypes.Snapshot{
+			CreatedOn:     time.Now().UTC(),
+			StartRevision: 1001,
+			LastRevision:  2000,
+			Kind:          brtypes.SnapshotKindDelta,
+		}
+		newSnap.GenerateSnapshotName()
+		err = store.Save(newSnap, io.NopCloser(bytes.NewReader(dummyData)))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		snapList, err = store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList.Len()).To(Equal(2))
+		Expect(backing.listCalls).To(Equal(2))
+
+		err = store.Delete(*snapList[1])
+		Expect(err).ShouldNot(HaveOccurred())
+
+		snapList, err = store.List()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(snapList.Len()).To(Equal(1))
+		Expect(backing.listCalls).To(Equal(3))
+	})
+})
+
+var _ = Describe("Abort incomplete multipart uploads on mock snapstore", func() {
+	It("should abort only the uploads initiated before the given time", func() {
+		var parts [][]byte
+		staleUploadID := "stale-upload"
+		recentUploadID := "recent-upload"
+		cli := &mockS3Client{
+			objects:          objectMap,
+			prefix:           prefixV2,
+			multiPartUploads: map[string]*[][]byte{staleUploadID: &parts, recentUploadID: &parts},
+			multiPartUploadsMeta: map[string]*s3.MultipartUpload{
+				staleUploadID: {
+					Key:       aws.String(path.Join(prefixV2, "Full-00000000-00001000-1111")),
+					UploadId:  aws.String(staleUploadID),
+					Initiated: aws.Time(time.Now().Add(-48 * time.Hour)),
+				},
+				recentUploadID: {
+					Key:       aws.String(path.Join(prefixV2, "Full-00001001-00002000-2222")),
+					UploadId:  aws.String(recentUploadID),
+					Initiated: aws.Time(time.Now()),
+				},
+			},
+		}
+		store := NewS3FromClient(bucket, prefixV2, "/tmp", 5, cli)
+		var _ brtypes.IncompleteUploadCleanerSnapStore = store
+
+		err := store.AbortIncompleteUploads(time.Now().Add(-24 * time.Hour))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cli.multiPartUploadsMeta).ShouldNot(HaveKey(staleUploadID))
+		Expect(cli.multiPartUploadsMeta).Should(HaveKey(recentUploadID))
+		Expect(cli.multiPartUploads).ShouldNot(HaveKey(staleUploadID))
+		Expect(cli.multiPartUploads).Should(HaveKey(recentUploadID))
+	})
+})
+
+// countingSnapStore wraps a snapstore and counts the listing calls made to it.
+type countingSnapStore struct {
+	brtypes.SnapStore
+	listCalls int
+}
+
+func (c *countingSnapStore) List() (brtypes.SnapList, error) {
+	c.listCalls++
+	return c.SnapStore.List()
+}
+
 func resetObjectMap() {
 	for k := range objectMap {
 		delete(objectMap, k)
@@ -57,9 +57,11 @@ type swiftCredentials struct {
 	BucketName string `json:"bucketName"`
 	DomainName string `json:"domainName"`
 	Password   string `json:"password"`
-	Region     string `json:"region"`
-	TenantName string `json:"tenantName"`
-	Username   string `json:"username"`
+	// ProjectName is the Keystone v3 name for the project, equivalent to TenantName.
+	ProjectName string `json:"projectName"`
+	Region      string `json:"region"`
+	TenantName  string `json:"tenantName"`
+	Username    string `json:"username"`
 }
 
 const (
@@ -139,10 +141,11 @@ func readSwiftCredentialsJSON(filename string) (*clientconfig.ClientOpts, error)
 	os.Setenv("OS_TENANT_NAME", cred.TenantName)
 	return &clientconfig.ClientOpts{
 		AuthInfo: &clientconfig.AuthInfo{
-			AuthURL:    cred.AuthURL,
-			DomainName: cred.DomainName,
-			Password:   cred.Password,
-			Username:   cred.Username,
+			AuthURL:     cred.AuthURL,
+			DomainName:  cred.DomainName,
+			Password:    cred.Password,
+			ProjectName: cred.ProjectName,
+			Username:    cred.Username,
 		},
 		RegionName: cred.Region,
 	}, nil
@@ -171,10 +174,11 @@ func readSwiftCredentialFiles(dirname string) (*clientconfig.ClientOpts, error)
 	os.Setenv("OS_TENANT_NAME", cred.TenantName)
 	return &clientconfig.ClientOpts{
 		AuthInfo: &clientconfig.AuthInfo{
-			AuthURL:    cred.AuthURL,
-			DomainName: cred.DomainName,
-			Password:   cred.Password,
-			Username:   cred.Username,
+			AuthURL:     cred.AuthURL,
+			DomainName:  cred.DomainName,
+			Password:    cred.Password,
+			ProjectName: cred.ProjectName,
+			Username:    cred.Username,
 		},
 		RegionName: cred.Region,
 	}, nil
@@ -212,6 +216,12 @@ func readSwiftCredentialDir(dirname string) (*swiftCredentials, error) {
 				return nil, err
 			}
 			cred.Region = string(data)
+		} else if file.Name() == "projectName" {
+			data, err := os.ReadFile(dirname + "/projectName")
+			if err != nil {
+				return nil, err
+			}
+			cred.ProjectName = string(data)
 		} else if file.Name() == "tenantName" {
 			data, err := os.ReadFile(dirname + "/tenantName")
 			if err != nil {
@@ -438,12 +448,13 @@ func SwiftSnapStoreHash(config *brtypes.SnapstoreConfig) (string, error) {
 }
 
 func getSwiftHash(config *swiftCredentials) string {
-	data := fmt.Sprintf("%s%s%s%s%s", config.AuthURL, config.TenantName, config.Username, config.DomainName, config.Password)
+	data := fmt.Sprintf("%s%s%s%s%s%s", config.AuthURL, config.TenantName, config.ProjectName, config.Username, config.DomainName, config.Password)
 	return getHash(data)
 }
 
 func isSwiftConfigEmpty(config *swiftCredentials) error {
-	if len(config.AuthURL) != 0 && len(config.TenantName) != 0 && len(config.Password) != 0 && len(config.Username) != 0 && len(config.DomainName) != 0 {
+	// The project can be identified by either the Keystone v3 projectName or the legacy tenantName.
+	if len(config.AuthURL) != 0 && (len(config.TenantName) != 0 || len(config.ProjectName) != 0) && len(config.Password) != 0 && len(config.Username) != 0 && len(config.DomainName) != 0 {
 		return nil
 	}
 	return fmt.Errorf("openstack swift credentials are not passed correctly")
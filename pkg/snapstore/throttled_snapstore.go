@@ -0,0 +1,178 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import (
+	"io"
+	"time"
+
+	brtypes "github.com/gardener/etcd-backup-restore/pkg/types"
+)
+
+// ThrottledSnapStore is a snapstore wrapper which limits the rate at which
+// snapshot data is uploaded to and downloaded from the backing store, so that
+// snapshot transfers do not saturate the network and starve the etcd peer
+// traffic. A rate of 0 leaves the respective direction unlimited.
+type ThrottledSnapStore struct {
+	store               brtypes.SnapStore
+	uploadBytesPerSec   int64
+	downloadBytesPerSec int64
+}
+
+// NewThrottledSnapStore returns a throttling wrapper around the given snapstore
+// which limits uploads and downloads to the given rates in bytes per second.
+func NewThrottledSnapStore(store brtypes.SnapStore, uploadBytesPerSec, downloadBytesPerSec int64) *ThrottledSnapStore {
+	return &ThrottledSnapStore{
+		store:               store,
+		uploadBytesPerSec:   uploadBytesPerSec,
+		downloadBytesPerSec: downloadBytesPerSec,
+	}
+}
+
+// Fetch should open reader for the snapshot file from store. The returned
+// reader is throttled to the configured download rate.
+func (s *ThrottledSnapStore) Fetch(snap brtypes.Snapshot) (io.ReadCloser, error) {
+	rc, err := s.store.Fetch(snap)
+	if err != nil || s.downloadBytesPerSec <= 0 {
+		return rc, err
+	}
+	return &throttledReadCloser{Reader: NewThrottledReader(rc, s.downloadBytesPerSec), Closer: rc}, nil
+}
+
+// List will return sorted list with all snapshot files on store.
+func (s *ThrottledSnapStore) List() (brtypes.SnapList, error) {
+	return s.store.List()
+}
+
+// Save will write the snapshot to store, reading the snapshot data at no more
+// than the configured upload rate.
+func (s *ThrottledSnapStore) Save(snap brtypes.Snapshot, rc io.ReadCloser) error {
+	if s.uploadBytesPerSec > 0 {
+		rc = &throttledReadCloser{Reader: NewThrottledReader(rc, s.uploadBytesPerSec), Closer: rc}
+	}
+	return s.store.Save(snap, rc)
+}
+
+// Delete should delete the snapshot file from store.
+func (s *ThrottledSnapStore) Delete(snap brtypes.Snapshot) error {
+	return s.store.Delete(snap)
+}
+
+// WrappedSnapStore returns the backing store, so that callers can discover
+// its optional capabilities, e.g. native pagination, which throttling does
+// not affect.
+func (s *ThrottledSnapStore) WrappedSnapStore() brtypes.SnapStore {
+	return s.store
+}
+
+// AbortIncompleteUploads aborts the incomplete multipart uploads initiated
+// before the given time, if the backing store supports it, and is a no-op
+// otherwise.
+func (s *ThrottledSnapStore) AbortIncompleteUploads(before time.Time) error {
+	if store, ok := s.store.(brtypes.IncompleteUploadCleanerSnapStore); ok {
+		return store.AbortIncompleteUploads(before)
+	}
+	return nil
+}
+
+// throttledReadCloser pairs a throttled reader with the closer of the
+// underlying stream.
+type throttledReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// throttledReader paces reads from the underlying reader so that the average
+// transfer rate does not exceed the configured number of bytes per second.
+type throttledReader struct {
+	reader      io.Reader
+	bytesPerSec int64
+	start       time.Time
+	transferred int64
+}
+
+// NewThrottledReader wraps the given reader so that on average at most
+// bytesPerSec bytes per second are read from it. A rate of 0 or less returns
+// the reader unchanged.
+func NewThrottledReader(reader io.Reader, bytesPerSec int64) io.Reader {
+	if bytesPerSec <= 0 {
+		return reader
+	}
+	return &throttledReader{reader: reader, bytesPerSec: bytesPerSec}
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if tr.start.IsZero() {
+		tr.start = time.Now()
+	}
+	// Cap the chunk size at one second worth of data to keep the pacing pauses short.
+	if int64(len(p)) > tr.bytesPerSec {
+		p = p[:tr.bytesPerSec]
+	}
+	n, err := tr.reader.Read(p)
+	tr.transferred += int64(n)
+	throttle(tr.start, tr.transferred, tr.bytesPerSec)
+	return n, err
+}
+
+// throttledWriter paces writes to the underlying writer so that the average
+// transfer rate does not exceed the configured number of bytes per second.
+type throttledWriter struct {
+	writer      io.Writer
+	bytesPerSec int64
+	start       time.Time
+	transferred int64
+}
+
+// NewThrottledWriter wraps the given writer so that on average at most
+// bytesPerSec bytes per second are written to it. A rate of 0 or less returns
+// the writer unchanged.
+func NewThrottledWriter(writer io.Writer, bytesPerSec int64) io.Writer {
+	if bytesPerSec <= 0 {
+		return writer
+	}
+	return &throttledWriter{writer: writer, bytesPerSec: bytesPerSec}
+}
+
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	if tw.start.IsZero() {
+		tw.start = time.Now()
+	}
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if int64(len(chunk)) > tw.bytesPerSec {
+			chunk = chunk[:tw.bytesPerSec]
+		}
+		n, err := tw.writer.Write(chunk)
+		written += n
+		tw.transferred += int64(n)
+		throttle(tw.start, tw.transferred, tw.bytesPerSec)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// throttle sleeps until the time by which the given number of bytes may have
+// been transferred at the given rate since start.
+func throttle(start time.Time, transferred, bytesPerSec int64) {
+	expected := time.Duration(float64(transferred) / float64(bytesPerSec) * float64(time.Second))
+	if elapsed := time.Since(start); elapsed < expected {
+		time.Sleep(expected - elapsed)
+	}
+}
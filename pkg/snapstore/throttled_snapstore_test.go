@@ -0,0 +1,70 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore_test
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	. "github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Throttled transfers", func() {
+	Describe("throttled reader", func() {
+		It("should not transfer faster than the configured rate", func() {
+			// 2048 bytes at 4096 bytes per second should take at least half a second.
+			data := make([]byte, 2048)
+			reader := NewThrottledReader(bytes.NewReader(data), 4096)
+
+			start := time.Now()
+			transferred, err := io.ReadAll(reader)
+			elapsed := time.Since(start)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(transferred).Should(HaveLen(len(data)))
+			Expect(elapsed).Should(BeNumerically(">=", 400*time.Millisecond))
+		})
+
+		It("should return the reader unchanged for a zero rate", func() {
+			reader := bytes.NewReader(make([]byte, 2048))
+			Expect(NewThrottledReader(reader, 0)).Should(BeIdenticalTo(reader))
+		})
+	})
+
+	Describe("throttled writer", func() {
+		It("should not transfer faster than the configured rate", func() {
+			data := make([]byte, 2048)
+			buffer := &bytes.Buffer{}
+			writer := NewThrottledWriter(buffer, 4096)
+
+			start := time.Now()
+			n, err := writer.Write(data)
+			elapsed := time.Since(start)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).Should(Equal(len(data)))
+			Expect(buffer.Len()).Should(Equal(len(data)))
+			Expect(elapsed).Should(BeNumerically(">=", 400*time.Millisecond))
+		})
+
+		It("should return the writer unchanged for a zero rate", func() {
+			buffer := &bytes.Buffer{}
+			Expect(NewThrottledWriter(buffer, 0)).Should(BeIdenticalTo(buffer))
+		})
+	})
+})
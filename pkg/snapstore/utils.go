@@ -66,6 +66,12 @@ func GetSnapstore(config *brtypes.SnapstoreConfig) (brtypes.SnapStore, error) {
 			return nil, fmt.Errorf("failed to get file info of temporary directory %s: %v", config.TempDir, err)
 		}
 	}
+	probe, err := os.CreateTemp(config.TempDir, "probe-")
+	if err != nil {
+		return nil, fmt.Errorf("temporary directory %s is not writable: %v", config.TempDir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
 
 	if config.MaxParallelChunkUploads <= 0 {
 		config.MaxParallelChunkUploads = 5
@@ -98,6 +104,64 @@ func GetSnapstore(config *brtypes.SnapstoreConfig) (brtypes.SnapStore, error) {
 	}
 }
 
+// ListSnapshotsInBatches invokes handler with successive batches of at most batchSize
+// snapshots from the store. If the store implements brtypes.PaginatedSnapStore, pages
+// are fetched lazily from the backend so that only one batch is held in memory at a
+// time. Pagination support is also discovered underneath any chain of snapstore
+// decorators, since decorators like the caching or throttling wrapper do not paginate
+// themselves. Stores without native pagination fall back to a single List call whose
+// result is split into batches.
+func ListSnapshotsInBatches(store brtypes.SnapStore, batchSize int64, handler func(brtypes.SnapList) error) error {
+	if paginatedStore, ok := findPaginatedSnapStore(store); ok {
+		var token string
+		for {
+			page, nextToken, err := paginatedStore.ListPage(token, batchSize)
+			if err != nil {
+				return err
+			}
+			if len(page) > 0 {
+				if err := handler(page); err != nil {
+					return err
+				}
+			}
+			if nextToken == "" {
+				return nil
+			}
+			token = nextToken
+		}
+	}
+
+	snapList, err := store.List()
+	if err != nil {
+		return err
+	}
+	for start := int64(0); start < int64(len(snapList)); start += batchSize {
+		end := start + batchSize
+		if end > int64(len(snapList)) {
+			end = int64(len(snapList))
+		}
+		if err := handler(snapList[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findPaginatedSnapStore unwraps snapstore decorators until it finds a store
+// with native pagination support.
+func findPaginatedSnapStore(store brtypes.SnapStore) (brtypes.PaginatedSnapStore, bool) {
+	for {
+		if paginatedStore, ok := store.(brtypes.PaginatedSnapStore); ok {
+			return paginatedStore, true
+		}
+		wrapper, ok := store.(brtypes.WrappingSnapStore)
+		if !ok {
+			return nil, false
+		}
+		store = wrapper.WrappedSnapStore()
+	}
+}
+
 // GetEnvVarOrError returns the value of specified environment variable or terminates if it's not defined.
 func GetEnvVarOrError(varName string) (string, error) {
 	value := os.Getenv(varName)
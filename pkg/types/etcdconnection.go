@@ -34,25 +34,56 @@ const (
 
 	// DefragRetryPeriod is used as the duration after which a defragmentation is retried.
 	DefragRetryPeriod time.Duration = 1 * time.Minute
+
+	// EndpointSelectionLeader routes snapshot requests to the current etcd leader.
+	EndpointSelectionLeader = "leader"
+	// EndpointSelectionFollower routes snapshot requests to an etcd follower, if one is available.
+	EndpointSelectionFollower = "follower"
+	// EndpointSelectionAny leaves the choice of the etcd member to the etcd client.
+	EndpointSelectionAny = "any"
 )
 
 // EtcdConnectionConfig holds the etcd connection config.
 type EtcdConnectionConfig struct {
 	// Endpoints are the endpoints from which the backup will be take or defragmentation will be called.
 	// This need not be necessary match the entire etcd cluster.
-	Endpoints          []string          `json:"endpoints"`
-	ServiceEndpoints   []string          `json:"serviceEndpoints,omitempty"`
-	Username           string            `json:"username,omitempty"`
-	Password           string            `json:"password,omitempty"`
-	ConnectionTimeout  wrappers.Duration `json:"connectionTimeout,omitempty"`
-	SnapshotTimeout    wrappers.Duration `json:"snapshotTimeout,omitempty"`
-	DefragTimeout      wrappers.Duration `json:"defragTimeout,omitempty"`
-	InsecureTransport  bool              `json:"insecureTransport,omitempty"`
-	InsecureSkipVerify bool              `json:"insecureSkipVerify,omitempty"`
-	CertFile           string            `json:"certFile,omitempty"`
-	KeyFile            string            `json:"keyFile,omitempty"`
-	CaFile             string            `json:"caFile,omitempty"`
-	MaxCallSendMsgSize int               `json:"maxCallSendMsgSize,omitempty"`
+	Endpoints        []string `json:"endpoints"`
+	ServiceEndpoints []string `json:"serviceEndpoints,omitempty"`
+	Username         string   `json:"username,omitempty"`
+	Password         string   `json:"password,omitempty"`
+	// UsernameFile is the path to a file holding the etcd username. The file is
+	// re-read every time a new etcd client is created, so that rotated
+	// credentials are picked up on the next (re)connect without a restart.
+	UsernameFile string `json:"usernameFile,omitempty"`
+	// PasswordFile is the path to a file holding the etcd password, re-read on
+	// every new etcd client connection like UsernameFile.
+	PasswordFile      string            `json:"passwordFile,omitempty"`
+	ConnectionTimeout wrappers.Duration `json:"connectionTimeout,omitempty"`
+	// DialTimeout bounds the initial connection establishment to an etcd endpoint.
+	// When unset, dialing is non-blocking, as it was before the field existed.
+	DialTimeout wrappers.Duration `json:"dialTimeout,omitempty"`
+	// RequestTimeout bounds individual etcd requests. When unset, ConnectionTimeout
+	// is used, as it was before the field existed.
+	RequestTimeout wrappers.Duration `json:"requestTimeout,omitempty"`
+	// DialKeepAliveTime is the interval at which client keepalive probes are sent
+	// over the connection. When unset, keepalive probing is disabled.
+	DialKeepAliveTime wrappers.Duration `json:"dialKeepAliveTime,omitempty"`
+	// DialKeepAliveTimeout is the duration the client waits for a response to a
+	// keepalive probe before closing the connection.
+	DialKeepAliveTimeout wrappers.Duration `json:"dialKeepAliveTimeout,omitempty"`
+	SnapshotTimeout      wrappers.Duration `json:"snapshotTimeout,omitempty"`
+	DefragTimeout        wrappers.Duration `json:"defragTimeout,omitempty"`
+	InsecureTransport    bool              `json:"insecureTransport,omitempty"`
+	InsecureSkipVerify   bool              `json:"insecureSkipVerify,omitempty"`
+	CertFile             string            `json:"certFile,omitempty"`
+	KeyFile              string            `json:"keyFile,omitempty"`
+	CaFile               string            `json:"caFile,omitempty"`
+	MaxCallSendMsgSize   int               `json:"maxCallSendMsgSize,omitempty"`
+	// EndpointSelection decides which etcd member role snapshot requests are routed to.
+	// The member roles are looked up via the etcd maintenance API, with each status call
+	// bound by ConnectionTimeout. If no member with the preferred role is reachable, the
+	// snapshot is taken from any of the configured endpoints.
+	EndpointSelection string `json:"endpointSelection,omitempty"`
 }
 
 // NewEtcdConnectionConfig returns etcd connection config.
@@ -64,6 +95,7 @@ func NewEtcdConnectionConfig() *EtcdConnectionConfig {
 		DefragTimeout:      wrappers.Duration{Duration: DefaultDefragConnectionTimeout},
 		InsecureTransport:  true,
 		InsecureSkipVerify: false,
+		EndpointSelection:  EndpointSelectionAny,
 	}
 }
 
@@ -73,7 +105,13 @@ func (c *EtcdConnectionConfig) AddFlags(fs *flag.FlagSet) {
 	fs.StringSliceVar(&c.ServiceEndpoints, "service-endpoints", c.ServiceEndpoints, "comma separated list of etcd endpoints that are used for etcd-backup-restore to connect to etcd through a (Kubernetes) service")
 	fs.StringVar(&c.Username, "etcd-username", c.Username, "etcd server username, if one is required")
 	fs.StringVar(&c.Password, "etcd-password", c.Password, "etcd server password, if one is required")
+	fs.StringVar(&c.UsernameFile, "etcd-username-file", c.UsernameFile, "path to a file holding the etcd server username, re-read on every new client connection so that rotated credentials are picked up without a restart")
+	fs.StringVar(&c.PasswordFile, "etcd-password-file", c.PasswordFile, "path to a file holding the etcd server password, re-read on every new client connection so that rotated credentials are picked up without a restart")
 	fs.DurationVar(&c.ConnectionTimeout.Duration, "etcd-connection-timeout", c.ConnectionTimeout.Duration, "etcd client connection timeout")
+	fs.DurationVar(&c.DialTimeout.Duration, "etcd-dial-timeout", c.DialTimeout.Duration, "timeout for establishing a connection to an etcd endpoint. If zero, dialing is non-blocking.")
+	fs.DurationVar(&c.RequestTimeout.Duration, "etcd-request-timeout", c.RequestTimeout.Duration, "timeout for individual etcd requests. If zero, the etcd connection timeout is used.")
+	fs.DurationVar(&c.DialKeepAliveTime.Duration, "etcd-dial-keepalive-time", c.DialKeepAliveTime.Duration, "interval at which client keepalive probes are sent to etcd. If zero, keepalive probing is disabled.")
+	fs.DurationVar(&c.DialKeepAliveTimeout.Duration, "etcd-dial-keepalive-timeout", c.DialKeepAliveTimeout.Duration, "duration to wait for a response to a keepalive probe before closing the connection")
 	fs.DurationVar(&c.SnapshotTimeout.Duration, "etcd-snapshot-timeout", c.SnapshotTimeout.Duration, "timeout duration for taking etcd snapshots")
 	fs.DurationVar(&c.DefragTimeout.Duration, "etcd-defrag-timeout", c.DefragTimeout.Duration, "timeout duration for etcd defrag call")
 	fs.BoolVar(&c.InsecureTransport, "insecure-transport", c.InsecureTransport, "disable transport security for client connections")
@@ -81,6 +119,16 @@ func (c *EtcdConnectionConfig) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&c.CertFile, "cert", c.CertFile, "identify secure client using this TLS certificate file")
 	fs.StringVar(&c.KeyFile, "key", c.KeyFile, "identify secure client using this TLS key file")
 	fs.StringVar(&c.CaFile, "cacert", c.CaFile, "verify certificates of TLS-enabled secure servers using this CA bundle")
+	fs.StringVar(&c.EndpointSelection, "endpoint-selection", c.EndpointSelection, "etcd member role to take snapshots from[leader/follower/any]")
+}
+
+// GetRequestTimeout returns the timeout for individual etcd requests, falling
+// back to the connection timeout when no dedicated request timeout is set.
+func (c *EtcdConnectionConfig) GetRequestTimeout() time.Duration {
+	if c.RequestTimeout.Duration > 0 {
+		return c.RequestTimeout.Duration
+	}
+	return c.ConnectionTimeout.Duration
 }
 
 // Validate validates the config.
@@ -97,5 +145,31 @@ func (c *EtcdConnectionConfig) Validate() error {
 	if c.DefragTimeout.Duration <= 0 {
 		return fmt.Errorf("etcd defrag timeout should be greater than zero")
 	}
+	if c.DialTimeout.Duration < 0 {
+		return fmt.Errorf("dial timeout should not be negative")
+	}
+	if c.RequestTimeout.Duration < 0 {
+		return fmt.Errorf("request timeout should not be negative")
+	}
+	if c.DialKeepAliveTime.Duration < 0 {
+		return fmt.Errorf("dial keepalive time should not be negative")
+	}
+	if c.DialKeepAliveTimeout.Duration < 0 {
+		return fmt.Errorf("dial keepalive timeout should not be negative")
+	}
+	if c.DialKeepAliveTimeout.Duration > 0 && c.DialKeepAliveTime.Duration == 0 {
+		return fmt.Errorf("dial keepalive timeout requires dial keepalive time to be set")
+	}
+	if c.Username != "" && c.UsernameFile != "" {
+		return fmt.Errorf("etcd username and username file are mutually exclusive")
+	}
+	if c.Password != "" && c.PasswordFile != "" {
+		return fmt.Errorf("etcd password and password file are mutually exclusive")
+	}
+	switch c.EndpointSelection {
+	case "", EndpointSelectionLeader, EndpointSelectionFollower, EndpointSelectionAny:
+	default:
+		return fmt.Errorf("invalid endpoint selection: %s", c.EndpointSelection)
+	}
 	return nil
 }
@@ -17,6 +17,7 @@ package types
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"path"
 	"time"
 
@@ -37,11 +38,44 @@ const (
 	defaultEmbeddedEtcdQuotaBytes   = 8 * 1024 * 1024 * 1024 //8Gib
 	defaultAutoCompactionMode       = "periodic"             // only 2 mode is supported: 'periodic' or 'revision'
 	defaultAutoCompactionRetention  = "30m"
+	// defaultDiskSpaceSafetyMarginPercent is the default safety margin applied on
+	// top of the estimated space required for a restoration.
+	defaultDiskSpaceSafetyMarginPercent = 10
 )
 
 // NewClientFactoryFunc allows to define how to create a client.Factory
 type NewClientFactoryFunc func(cfg EtcdConnectionConfig, opts ...client.Option) client.Factory
 
+// Phases of a restoration exposed via the /restore/status HTTP endpoint.
+const (
+	// RestorePhaseNew indicates no restoration has been started yet.
+	RestorePhaseNew = "New"
+	// RestorePhaseFetching indicates the base snapshot is being fetched.
+	RestorePhaseFetching = "Fetching"
+	// RestorePhaseApplying indicates delta snapshots are being applied.
+	RestorePhaseApplying = "Applying"
+	// RestorePhaseDone indicates the restoration finished successfully.
+	RestorePhaseDone = "Done"
+	// RestorePhaseFailed indicates the restoration failed.
+	RestorePhaseFailed = "Failed"
+)
+
+// RestoreProgress describes how far a restoration has progressed.
+type RestoreProgress struct {
+	// Phase is the phase the restoration is currently in.
+	Phase string `json:"phase"`
+	// CurrentSnapshot is the name of the snapshot currently being fetched or applied.
+	CurrentSnapshot string `json:"currentSnapshot,omitempty"`
+	// DeltasApplied is the number of delta snapshots applied so far.
+	DeltasApplied int `json:"deltasApplied"`
+	// DeltasTotal is the total number of delta snapshots to apply.
+	DeltasTotal int `json:"deltasTotal"`
+	// CurrentRevision is the etcd revision the restoration has reached so far.
+	CurrentRevision int64 `json:"currentRevision"`
+	// Message holds the failure reason when the restoration failed.
+	Message string `json:"message,omitempty"`
+}
+
 // RestoreOptions hold all snapshot restore related fields
 // Note: Please ensure DeepCopy and DeepCopyInto are properly implemented.
 type RestoreOptions struct {
@@ -72,6 +106,38 @@ type RestorationConfig struct {
 	EmbeddedEtcdQuotaBytes   int64    `json:"embeddedEtcdQuotaBytes,omitempty"`
 	AutoCompactionMode       string   `json:"autoCompactionMode,omitempty"`
 	AutoCompactionRetention  string   `json:"autoCompactionRetention,omitempty"`
+	// FullSnapshotName is the object name of the full snapshot to restore from,
+	// bypassing the latest snapshot selection.
+	FullSnapshotName string `json:"fullSnapshotName,omitempty"`
+	// DeltaSnapshotNames are the object names of the delta snapshots to replay on
+	// top of the named full snapshot.
+	DeltaSnapshotNames []string `json:"deltaSnapshotNames,omitempty"`
+	// AllowDeltaSnapshotGaps lets the restoration proceed across gaps in the
+	// delta snapshot chain, which otherwise fail the restoration.
+	AllowDeltaSnapshotGaps bool `json:"allowDeltaSnapshotGaps,omitempty"`
+	// BackupExistingDataDir moves an existing member directory to a timestamped
+	// backup location before restoration, rolling back to it if the restoration
+	// fails. On success the backup is left behind for manual cleanup.
+	BackupExistingDataDir bool `json:"backupExistingDataDir,omitempty"`
+	// DiskSpaceSafetyMarginPercent is the safety margin, in percent, applied on
+	// top of the space estimated from the base snapshot and the delta chain when
+	// validating the available disk space before a restoration.
+	DiskSpaceSafetyMarginPercent uint `json:"diskSpaceSafetyMarginPercent,omitempty"`
+	// TempDir is the directory for the temporary files staged during a
+	// restoration, such as fetched delta snapshot events. When unset the
+	// system default temporary directory is used.
+	TempDir string `json:"tempDir,omitempty"`
+	// DownloadBytesPerSecond limits the rate at which snapshot data is downloaded
+	// from the snapstore during a restoration, so that downloads do not saturate
+	// the network. 0 means unlimited.
+	DownloadBytesPerSecond int64 `json:"downloadBytesPerSecond,omitempty"`
+	// JoinExistingClusterAsLearner configures the restored member to join an
+	// existing cluster as a learner instead of bootstrapping a fresh
+	// single-member cluster. InitialCluster must then hold the peer URLs of all
+	// existing members plus this member, and Name must identify this member in
+	// that list. The member must have been registered with the cluster as a
+	// learner before starting etcd with the generated config.
+	JoinExistingClusterAsLearner bool `json:"joinExistingClusterAsLearner,omitempty"`
 }
 
 // NewRestorationConfig returns the restoration config.
@@ -90,6 +156,8 @@ func NewRestorationConfig() *RestorationConfig {
 		EmbeddedEtcdQuotaBytes:   int64(defaultEmbeddedEtcdQuotaBytes),
 		AutoCompactionMode:       defaultAutoCompactionMode,
 		AutoCompactionRetention:  defaultAutoCompactionRetention,
+
+		DiskSpaceSafetyMarginPercent: defaultDiskSpaceSafetyMarginPercent,
 	}
 }
 
@@ -108,6 +176,14 @@ func (c *RestorationConfig) AddFlags(fs *flag.FlagSet) {
 	fs.Int64Var(&c.EmbeddedEtcdQuotaBytes, "embedded-etcd-quota-bytes", c.EmbeddedEtcdQuotaBytes, "maximum backend quota for the embedded etcd used for applying delta snapshots")
 	fs.StringVar(&c.AutoCompactionMode, "auto-compaction-mode", c.AutoCompactionMode, "mode for auto-compaction: 'periodic' for duration based retention. 'revision' for revision number based retention.")
 	fs.StringVar(&c.AutoCompactionRetention, "auto-compaction-retention", c.AutoCompactionRetention, "Auto-compaction retention length.")
+	fs.StringVar(&c.FullSnapshotName, "full-snapshot-name", c.FullSnapshotName, "object name of the full snapshot to restore from, bypassing the latest snapshot selection")
+	fs.StringSliceVar(&c.DeltaSnapshotNames, "delta-snapshot-names", c.DeltaSnapshotNames, "comma separated object names of the delta snapshots to replay on top of the named full snapshot")
+	fs.BoolVar(&c.AllowDeltaSnapshotGaps, "allow-delta-snapshot-gaps", c.AllowDeltaSnapshotGaps, "proceed with restoration even if the delta snapshot chain has gaps in revision numbering")
+	fs.BoolVar(&c.BackupExistingDataDir, "backup-existing-data-dir", c.BackupExistingDataDir, "move an existing member directory to a timestamped backup location before restoration and roll back to it if the restoration fails")
+	fs.UintVar(&c.DiskSpaceSafetyMarginPercent, "disk-space-safety-margin-percent", c.DiskSpaceSafetyMarginPercent, "safety margin in percent applied on top of the estimated space required for a restoration when validating the available disk space")
+	fs.StringVar(&c.TempDir, "restore-temp-directory", c.TempDir, "directory for the temporary files staged during a restoration")
+	fs.Int64Var(&c.DownloadBytesPerSecond, "download-bytes-per-second", c.DownloadBytesPerSecond, "Maximum rate in bytes per second at which snapshot data is downloaded from the snapstore during a restoration. If this value is set to 0, downloads are not rate limited.")
+	fs.BoolVar(&c.JoinExistingClusterAsLearner, "join-existing-cluster-as-learner", c.JoinExistingClusterAsLearner, "configure the restored member to join an existing cluster as a learner instead of bootstrapping a new cluster")
 }
 
 // Validate validates the config.
@@ -130,6 +206,36 @@ func (c *RestorationConfig) Validate() error {
 	if c.AutoCompactionMode != "periodic" && c.AutoCompactionMode != "revision" {
 		return fmt.Errorf("UnSupported auto-compaction-mode")
 	}
+	if len(c.DeltaSnapshotNames) > 0 && len(c.FullSnapshotName) == 0 {
+		return fmt.Errorf("delta snapshot names can only be given together with a full snapshot name")
+	}
+	if c.DownloadBytesPerSecond < 0 {
+		return fmt.Errorf("download rate limit in bytes per second cannot be negative")
+	}
+	if c.JoinExistingClusterAsLearner {
+		clusterURLsMap, err := types.NewURLsMap(c.InitialCluster)
+		if err != nil {
+			return fmt.Errorf("failed creating url map for restore cluster: %v", err)
+		}
+		if _, ok := clusterURLsMap[c.Name]; !ok {
+			return fmt.Errorf("initial cluster %s does not contain this member %s required to join the existing cluster", c.InitialCluster, c.Name)
+		}
+	}
+	if c.TempDir != "" {
+		info, err := os.Stat(c.TempDir)
+		if err != nil {
+			return fmt.Errorf("temp directory %s is not accessible: %v", c.TempDir, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("temp directory %s is not a directory", c.TempDir)
+		}
+		probe, err := os.CreateTemp(c.TempDir, "probe-")
+		if err != nil {
+			return fmt.Errorf("temp directory %s is not writable: %v", c.TempDir, err)
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+	}
 	c.RestoreDataDir = path.Clean(c.RestoreDataDir)
 	return nil
 }
@@ -144,6 +250,13 @@ func (c *RestorationConfig) DeepCopyInto(out *RestorationConfig) {
 			(*out)[i] = v
 		}
 	}
+	if c.DeltaSnapshotNames != nil {
+		c, out := &c.DeltaSnapshotNames, &out.DeltaSnapshotNames
+		*out = make([]string, len(*c))
+		for i, v := range *c {
+			(*out)[i] = v
+		}
+	}
 }
 
 // DeepCopy returns a deeply copied structure.
@@ -16,6 +16,7 @@ package types
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gardener/etcd-backup-restore/pkg/wrappers"
@@ -49,6 +50,26 @@ const (
 
 	// DeltaSnapshotIntervalThreshold is interval between delta snapshot
 	DeltaSnapshotIntervalThreshold = time.Second
+
+	// DefaultErrorHistoryLimit is the default number of recent snapshot and
+	// garbage collection errors retained for the /snapshot/errors HTTP endpoint.
+	DefaultErrorHistoryLimit = 10
+
+	// DefaultBreakerOpenWindow is the default duration for which snapshot attempts
+	// are skipped once the snapshot circuit breaker has opened.
+	DefaultBreakerOpenWindow = 5 * time.Minute
+
+	// DeltaSnapshotChainLimitActionWarn logs a warning and raises a metric when
+	// the delta snapshot chain limit is exceeded.
+	DeltaSnapshotChainLimitActionWarn = "warn"
+	// DeltaSnapshotChainLimitActionSnapshot takes an out-of-schedule full snapshot
+	// when the delta snapshot chain limit is exceeded.
+	DeltaSnapshotChainLimitActionSnapshot = "snapshot"
+
+	// DeltaSnapshotEncodingJSON is constant for the JSON delta snapshot encoding.
+	DeltaSnapshotEncodingJSON = "json"
+	// DeltaSnapshotEncodingProtobuf is constant for the compact protobuf delta snapshot encoding.
+	DeltaSnapshotEncodingProtobuf = "protobuf"
 )
 
 // SnapshotterState denotes the state the snapshotter would be in.
@@ -56,12 +77,77 @@ type SnapshotterState int
 
 // SnapshotterConfig holds the snapshotter config.
 type SnapshotterConfig struct {
-	FullSnapshotSchedule     string            `json:"schedule,omitempty"`
-	DeltaSnapshotPeriod      wrappers.Duration `json:"deltaSnapshotPeriod,omitempty"`
-	DeltaSnapshotMemoryLimit uint              `json:"deltaSnapshotMemoryLimit,omitempty"`
-	GarbageCollectionPeriod  wrappers.Duration `json:"garbageCollectionPeriod,omitempty"`
-	GarbageCollectionPolicy  string            `json:"garbageCollectionPolicy,omitempty"`
-	MaxBackups               uint              `json:"maxBackups,omitempty"`
+	FullSnapshotSchedule        string            `json:"schedule,omitempty"`
+	DeltaSnapshotPeriod         wrappers.Duration `json:"deltaSnapshotPeriod,omitempty"`
+	DeltaSnapshotMemoryLimit    uint              `json:"deltaSnapshotMemoryLimit,omitempty"`
+	DeltaSnapshotEventThreshold uint              `json:"deltaSnapshotEventThreshold,omitempty"`
+	// DeltaSnapshotEncoding selects the serialization format for the events in
+	// delta snapshots, either "json" or the more compact "protobuf". The
+	// restorer detects the encoding from the snapshot payload, so snapshots
+	// taken with either encoding can always be restored.
+	DeltaSnapshotEncoding string `json:"deltaSnapshotEncoding,omitempty"`
+	// KeyPrefixFilter scopes snapshots to the keys under the given
+	// comma-separated prefixes. Full snapshots are then taken as a key-range
+	// export instead of an etcd database snapshot, and delta watches only record
+	// events under the prefixes. Restoring such snapshots replays the exported
+	// keys, so the restored etcd does not retain the revision numbers of the
+	// original cluster.
+	KeyPrefixFilter         string            `json:"keyPrefixFilter,omitempty"`
+	GarbageCollectionPeriod wrappers.Duration `json:"garbageCollectionPeriod,omitempty"`
+	GarbageCollectionPolicy string            `json:"garbageCollectionPolicy,omitempty"`
+	MaxBackups              uint              `json:"maxBackups,omitempty"`
+	// PreSnapshotHook is a command or webhook URL executed immediately before each full snapshot.
+	PreSnapshotHook string `json:"preSnapshotHook,omitempty"`
+	// PostSnapshotHook is a command or webhook URL executed immediately after each full snapshot.
+	PostSnapshotHook string `json:"postSnapshotHook,omitempty"`
+	// AbortOnHookFailure aborts the full snapshot when a snapshot hook fails instead of only logging the failure.
+	AbortOnHookFailure bool `json:"abortOnHookFailure,omitempty"`
+	// SnapshotFailureThreshold is the number of consecutive snapshot failures after
+	// which the circuit breaker opens and snapshot attempts are skipped for the
+	// breaker open window. 0 disables the breaker so that snapshots are always attempted.
+	SnapshotFailureThreshold uint `json:"snapshotFailureThreshold,omitempty"`
+	// BreakerOpenWindow is the duration for which snapshot attempts are skipped once
+	// the circuit breaker has opened, before a single probe attempt is allowed again.
+	BreakerOpenWindow wrappers.Duration `json:"breakerOpenWindow,omitempty"`
+	// ErrorHistoryLimit is the number of recent snapshot and garbage collection
+	// errors retained for the /snapshot/errors HTTP endpoint. 0 disables the
+	// error history.
+	ErrorHistoryLimit uint `json:"errorHistoryLimit,omitempty"`
+	// BackupValidationPeriod is the period after which the latest snapshot chain
+	// is validated in the background by restoring it into a temporary directory.
+	// 0 disables backup validation.
+	BackupValidationPeriod wrappers.Duration `json:"backupValidationPeriod,omitempty"`
+	// SnapshotOnlyIfLeader skips full snapshots when the local etcd member is not
+	// the cluster leader, so that in an HA setup only the leader's sidecar takes
+	// snapshots.
+	SnapshotOnlyIfLeader bool `json:"snapshotOnlyIfLeader,omitempty"`
+	// ScheduleJitter delays each scheduled full snapshot by a random offset that
+	// is stable for the lifetime of the process, so that replicas sharing the
+	// same schedule do not all hit the object store and their etcds at once. The
+	// jitter should be smaller than the schedule period. 0 disables jitter.
+	ScheduleJitter wrappers.Duration `json:"scheduleJitter,omitempty"`
+	// UploadBytesPerSecond limits the rate at which snapshot data is uploaded to
+	// the snapstore, so that uploads do not saturate the network and starve the
+	// etcd peer traffic. 0 means unlimited.
+	UploadBytesPerSecond int64 `json:"uploadBytesPerSecond,omitempty"`
+	// DeltaSnapshotChainLimit is the number of delta snapshots since the last
+	// full snapshot after which the chain limit action is triggered, so that
+	// the time to restore from the chain stays bounded. 0 disables the check.
+	DeltaSnapshotChainLimit uint `json:"deltaSnapshotChainLimit,omitempty"`
+	// DeltaSnapshotChainLimitAction is the action taken when the delta snapshot
+	// chain limit is exceeded: "warn" logs a warning and raises a metric, while
+	// "snapshot" takes an out-of-schedule full snapshot to cut the chain.
+	DeltaSnapshotChainLimitAction string `json:"deltaSnapshotChainLimitAction,omitempty"`
+	// FinalDeltaSnapshotOnShutdown takes one last delta snapshot of the events
+	// accumulated since the previous snapshot when the snapshotter is stopped, so
+	// that a graceful shutdown does not lose the most recent etcd writes from the
+	// backup. The final delta snapshot is bounded by a grace period so that it
+	// cannot hang the shutdown indefinitely.
+	FinalDeltaSnapshotOnShutdown bool `json:"finalDeltaSnapshotOnShutdown,omitempty"`
+	// FailOnSnapshotReplicationError fails the snapshot when replication to a
+	// configured replica snapstore fails, instead of only logging and counting
+	// the failure while keeping the snapshot cycle going.
+	FailOnSnapshotReplicationError bool `json:"failOnSnapshotReplicationError,omitempty"`
 }
 
 // AddFlags adds the flags to flagset.
@@ -69,9 +155,38 @@ func (c *SnapshotterConfig) AddFlags(fs *flag.FlagSet) {
 	fs.StringVarP(&c.FullSnapshotSchedule, "schedule", "s", c.FullSnapshotSchedule, "schedule for snapshots")
 	fs.DurationVar(&c.DeltaSnapshotPeriod.Duration, "delta-snapshot-period", c.DeltaSnapshotPeriod.Duration, "Period after which delta snapshot will be persisted. If this value is set to be lesser than 1, delta snapshotting will be disabled.")
 	fs.UintVar(&c.DeltaSnapshotMemoryLimit, "delta-snapshot-memory-limit", c.DeltaSnapshotMemoryLimit, "memory limit after which delta snapshots will be taken")
+	fs.UintVar(&c.DeltaSnapshotEventThreshold, "delta-snapshot-event-threshold", c.DeltaSnapshotEventThreshold, "Number of collected etcd events after which a delta snapshot will be taken, in addition to the periodic and memory based triggers. If this value is set to 0, event count based delta snapshotting is disabled.")
+	fs.StringVar(&c.DeltaSnapshotEncoding, "delta-snapshot-encoding", c.DeltaSnapshotEncoding, "serialization format for events in delta snapshots, either 'json' or 'protobuf'")
+	fs.StringVar(&c.KeyPrefixFilter, "key-prefix-filter", c.KeyPrefixFilter, "comma separated list of key prefixes to snapshot; full snapshots are taken as a key-range export instead of an etcd database snapshot and delta watches are scoped to the prefixes")
 	fs.DurationVar(&c.GarbageCollectionPeriod.Duration, "garbage-collection-period", c.GarbageCollectionPeriod.Duration, "Period for garbage collecting old backups")
 	fs.StringVar(&c.GarbageCollectionPolicy, "garbage-collection-policy", c.GarbageCollectionPolicy, "Policy for garbage collecting old backups")
 	fs.UintVarP(&c.MaxBackups, "max-backups", "m", c.MaxBackups, "maximum number of previous backups to keep")
+	fs.StringVar(&c.PreSnapshotHook, "pre-snapshot-hook", c.PreSnapshotHook, "command or webhook URL to execute immediately before each full snapshot")
+	fs.StringVar(&c.PostSnapshotHook, "post-snapshot-hook", c.PostSnapshotHook, "command or webhook URL to execute immediately after each full snapshot")
+	fs.BoolVar(&c.AbortOnHookFailure, "abort-snapshot-on-hook-failure", c.AbortOnHookFailure, "abort the full snapshot when a snapshot hook fails instead of only logging the failure")
+	fs.UintVar(&c.SnapshotFailureThreshold, "snapshot-failure-threshold", c.SnapshotFailureThreshold, "Number of consecutive snapshot failures after which snapshot attempts are skipped for the breaker open window. If this value is set to 0, the circuit breaker is disabled and snapshots are always attempted.")
+	fs.DurationVar(&c.BreakerOpenWindow.Duration, "breaker-open-window", c.BreakerOpenWindow.Duration, "duration for which snapshot attempts are skipped once the circuit breaker has opened")
+	fs.UintVar(&c.ErrorHistoryLimit, "snapshot-error-history-limit", c.ErrorHistoryLimit, "Number of recent snapshot and garbage collection errors retained for the /snapshot/errors HTTP endpoint. If this value is set to 0, the error history is disabled.")
+	fs.DurationVar(&c.BackupValidationPeriod.Duration, "backup-validation-period", c.BackupValidationPeriod.Duration, "Period after which the latest snapshot chain will be validated by restoring it into a temporary directory. If this value is set to 0, backup validation is disabled.")
+	fs.BoolVar(&c.SnapshotOnlyIfLeader, "snapshot-only-if-leader", c.SnapshotOnlyIfLeader, "skip full snapshots when the local etcd member is not the cluster leader")
+	fs.DurationVar(&c.ScheduleJitter.Duration, "schedule-jitter", c.ScheduleJitter.Duration, "Maximum random delay added to each scheduled full snapshot, stable for the lifetime of the process, to spread the load of replicas sharing the same schedule. Should be smaller than the schedule period. If this value is set to 0, no jitter is applied.")
+	fs.Int64Var(&c.UploadBytesPerSecond, "upload-bytes-per-second", c.UploadBytesPerSecond, "Maximum rate in bytes per second at which snapshot data is uploaded to the snapstore. If this value is set to 0, uploads are not rate limited.")
+	fs.UintVar(&c.DeltaSnapshotChainLimit, "delta-snapshot-chain-limit", c.DeltaSnapshotChainLimit, "Number of delta snapshots since the last full snapshot after which the delta snapshot chain limit action is triggered. If this value is set to 0, the check is disabled.")
+	fs.StringVar(&c.DeltaSnapshotChainLimitAction, "delta-snapshot-chain-limit-action", c.DeltaSnapshotChainLimitAction, "action taken when the delta snapshot chain limit is exceeded, either 'warn' or 'snapshot'")
+	fs.BoolVar(&c.FinalDeltaSnapshotOnShutdown, "final-delta-snapshot-on-shutdown", c.FinalDeltaSnapshotOnShutdown, "take one last delta snapshot of the accumulated events when the snapshotter is stopped, so that a graceful shutdown does not lose the most recent etcd writes from the backup")
+	fs.BoolVar(&c.FailOnSnapshotReplicationError, "fail-on-snapshot-replication-error", c.FailOnSnapshotReplicationError, "fail the snapshot when replication to the replica snapstore fails instead of only logging the failure")
+}
+
+// KeyPrefixFilters returns the configured key prefix filters as a list. An
+// empty filter yields no prefixes, i.e. the whole keyspace is snapshotted.
+func (c *SnapshotterConfig) KeyPrefixFilters() []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(c.KeyPrefixFilter, ",") {
+		if prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
 }
 
 // Validate validates the config.
@@ -90,9 +205,43 @@ func (c *SnapshotterConfig) Validate() error {
 		logrus.Infof("Found delta snapshot interval %s less than 1 second. Disabling delta snapshotting. ", c.DeltaSnapshotPeriod)
 	}
 
+	prefixes := c.KeyPrefixFilters()
+	for i, p := range prefixes {
+		for j, q := range prefixes {
+			if i != j && strings.HasPrefix(q, p) {
+				return fmt.Errorf("key prefix filters must not overlap: %q contains %q", p, q)
+			}
+		}
+	}
+
+	switch c.DeltaSnapshotEncoding {
+	case "", DeltaSnapshotEncodingJSON, DeltaSnapshotEncodingProtobuf:
+	default:
+		return fmt.Errorf("invalid delta snapshot encoding: %s", c.DeltaSnapshotEncoding)
+	}
+
 	if c.DeltaSnapshotMemoryLimit < 1 {
 		logrus.Infof("Found delta snapshot memory limit %d bytes less than 1 byte. Setting it to default: %d ", c.DeltaSnapshotMemoryLimit, DefaultDeltaSnapMemoryLimit)
 		c.DeltaSnapshotMemoryLimit = DefaultDeltaSnapMemoryLimit
 	}
+
+	switch c.DeltaSnapshotChainLimitAction {
+	case "", DeltaSnapshotChainLimitActionWarn, DeltaSnapshotChainLimitActionSnapshot:
+	default:
+		return fmt.Errorf("invalid delta snapshot chain limit action: %s", c.DeltaSnapshotChainLimitAction)
+	}
+
+	if c.ScheduleJitter.Duration < 0 {
+		return fmt.Errorf("schedule jitter cannot be negative")
+	}
+
+	if c.UploadBytesPerSecond < 0 {
+		return fmt.Errorf("upload rate limit in bytes per second cannot be negative")
+	}
+
+	if c.SnapshotFailureThreshold > 0 && c.BreakerOpenWindow.Duration <= 0 {
+		logrus.Infof("Found breaker open window %s not greater than zero. Setting it to default: %s ", c.BreakerOpenWindow.Duration, time.Duration(DefaultBreakerOpenWindow))
+		c.BreakerOpenWindow = wrappers.Duration{Duration: DefaultBreakerOpenWindow}
+	}
 	return nil
 }
@@ -58,7 +58,17 @@ const (
 	// FinalSuffix is the suffix appended to the names of final snapshots.
 	FinalSuffix = ".final"
 
+	// ObjectLayoutFlat is constant for the flat object layout which places
+	// snapshot objects directly under the store prefix.
+	ObjectLayoutFlat = "flat"
+	// ObjectLayoutDate is constant for the date object layout which places
+	// snapshot objects under a YYYY/MM/DD directory derived from their
+	// creation time.
+	ObjectLayoutDate = "date"
+
 	backupFormatVersion = "v2"
+
+	dateDirectoryLayout = "2006/01/02"
 )
 
 // SnapStore is the interface to be implemented for different
@@ -76,6 +86,48 @@ type SnapStore interface {
 	Delete(Snapshot) error
 }
 
+// PaginatedSnapStore is an optional extension of SnapStore for storage
+// backends which support server-side pagination of snapshot listings.
+// Implementations return at most limit snapshots per call along with a
+// continuation token to fetch the next page. An empty returned token
+// indicates that the listing is complete. Snapshots within a page are
+// sorted, but ordering across pages is provider-specific, so callers
+// requiring a fully sorted listing must sort the aggregated result.
+type PaginatedSnapStore interface {
+	SnapStore
+	// ListPage returns one page of at most limit snapshots starting at the
+	// position encoded in the given continuation token.
+	ListPage(token string, limit int64) (SnapList, string, error)
+}
+
+// WrappingSnapStore is implemented by snapstore decorators which delegate to
+// another snapstore, so that callers can discover optional capabilities of
+// the store underneath the decoration.
+type WrappingSnapStore interface {
+	SnapStore
+	// WrappedSnapStore returns the snapstore this store delegates to.
+	WrappedSnapStore() SnapStore
+}
+
+// SizedSnapStore is an optional extension of SnapStore for storage backends
+// which can report the size of a stored snapshot object without fetching it.
+type SizedSnapStore interface {
+	SnapStore
+	// Size returns the size of the given snapshot object in bytes.
+	Size(Snapshot) (int64, error)
+}
+
+// IncompleteUploadCleanerSnapStore is an optional extension of SnapStore for
+// storage backends which can clean up leftovers of interrupted snapshot
+// uploads, such as incomplete multipart uploads or snapshot objects staged
+// under a temporary name.
+type IncompleteUploadCleanerSnapStore interface {
+	SnapStore
+	// AbortIncompleteUploads aborts the incomplete uploads initiated before
+	// the given time.
+	AbortIncompleteUploads(before time.Time) error
+}
+
 // Snapshot structure represents the metadata of snapshot.s
 type Snapshot struct {
 	Kind              string    `json:"kind"` //incr:incremental,full:full
@@ -100,6 +152,12 @@ func (s *Snapshot) GenerateSnapshotDirectory() {
 	s.SnapDir = fmt.Sprintf("Backup-%d", s.CreatedOn.Unix())
 }
 
+// GenerateDateDirectory places the snapshot under a YYYY/MM/DD directory
+// derived from its creation time. It is used with the date object layout.
+func (s *Snapshot) GenerateDateDirectory() {
+	s.SnapDir = s.CreatedOn.UTC().Format(dateDirectoryLayout)
+}
+
 // GetSnapshotDirectoryCreationTimeInUnix returns the creation time for snapshot directory.
 func (s *Snapshot) GetSnapshotDirectoryCreationTimeInUnix() (int64, error) {
 	tok := strings.TrimPrefix(s.SnapDir, "Backup-")
@@ -166,6 +224,9 @@ type SnapstoreConfig struct {
 	Container string `json:"container"`
 	// Prefix holds the prefix or directory under StorageContainer under which snapshot will be stored.
 	Prefix string `json:"prefix,omitempty"`
+	// ObjectLayout selects the layout of snapshot object names under Prefix,
+	// either "flat" or "date" for YYYY/MM/DD partitioning by creation time.
+	ObjectLayout string `json:"objectLayout,omitempty"`
 	// MaxParallelChunkUploads hold the maximum number of parallel chunk uploads allowed.
 	MaxParallelChunkUploads uint `json:"maxParallelChunkUploads,omitempty"`
 	// Temporary Directory
@@ -184,10 +245,16 @@ func (c *SnapstoreConfig) AddSourceFlags(fs *flag.FlagSet) {
 	c.addFlags(fs, "source-")
 }
 
+// AddReplicaFlags adds the flags to flagset using `replica-` prefix for all parameters.
+func (c *SnapstoreConfig) AddReplicaFlags(fs *flag.FlagSet) {
+	c.addFlags(fs, "replica-")
+}
+
 func (c *SnapstoreConfig) addFlags(fs *flag.FlagSet, parameterPrefix string) {
 	fs.StringVar(&c.Provider, parameterPrefix+"storage-provider", c.Provider, "snapshot storage provider")
 	fs.StringVar(&c.Container, parameterPrefix+"store-container", c.Container, "container which will be used as snapstore")
 	fs.StringVar(&c.Prefix, parameterPrefix+"store-prefix", c.Prefix, "prefix or directory inside container under which snapstore is created")
+	fs.StringVar(&c.ObjectLayout, parameterPrefix+"store-object-layout", c.ObjectLayout, "layout of snapshot object names under the store prefix, either 'flat' or 'date'")
 	fs.UintVar(&c.MaxParallelChunkUploads, parameterPrefix+"max-parallel-chunk-uploads", c.MaxParallelChunkUploads, "maximum number of parallel chunk uploads allowed ")
 	fs.StringVar(&c.TempDir, parameterPrefix+"snapstore-temp-directory", c.TempDir, "temporary directory for processing")
 }
@@ -197,6 +264,11 @@ func (c *SnapstoreConfig) Validate() error {
 	if c.MaxParallelChunkUploads <= 0 {
 		return fmt.Errorf("max parallel chunk uploads should be greater than zero")
 	}
+	switch c.ObjectLayout {
+	case "", ObjectLayoutFlat, ObjectLayoutDate:
+	default:
+		return fmt.Errorf("object layout should be either %q or %q", ObjectLayoutFlat, ObjectLayoutDate)
+	}
 	return nil
 }
 
@@ -215,6 +287,9 @@ func (c *SnapstoreConfig) MergeWith(other *SnapstoreConfig) {
 	} else {
 		c.Prefix = path.Join(c.Prefix, backupFormatVersion)
 	}
+	if c.ObjectLayout == "" {
+		c.ObjectLayout = other.ObjectLayout
+	}
 	if c.TempDir == "" {
 		c.TempDir = other.TempDir
 	}